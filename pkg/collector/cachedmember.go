@@ -0,0 +1,244 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// cachedMember wraps a Member and retains the metrics of its most
+// recent successful refresh. Refreshes are either done in the background (see
+// [cachedMember.run]) or on scrapes once the snapshot is older than the
+// configured interval.
+type cachedMember struct {
+	id     string
+	member Member
+
+	// Minimum duration between refreshes.
+	interval time.Duration
+
+	// Impose a timeout on each refresh if non-zero.
+	timeout time.Duration
+
+	// Whether refreshes are driven by [cachedMember.run] instead of scrapes.
+	background bool
+
+	// Logger made available to the wrapped member during background
+	// refreshes, if non-nil.
+	logger *slog.Logger
+
+	now func() time.Time
+
+	// Outcome of refreshes, if non-nil. Scrapes served from the snapshot are
+	// not recorded.
+	results *memberResults
+
+	lastSuccessDesc *prometheus.Desc
+	cacheAgeDesc    *prometheus.Desc
+	staleDesc       *prometheus.Desc
+
+	// Serializes scrape-driven refreshes.
+	refreshMu sync.Mutex
+
+	mu          sync.Mutex
+	metrics     []prometheus.Metric
+	lastAttempt time.Time
+	lastSuccess time.Time
+	lastErr     error
+}
+
+var _ Member = (*cachedMember)(nil)
+
+func newCachedMember(id string, m Member, interval time.Duration) *cachedMember {
+	return &cachedMember{
+		id:       id,
+		member:   m,
+		interval: interval,
+		now:      time.Now,
+
+		lastSuccessDesc: prometheus.NewDesc("paperless_collector_last_success_timestamp_seconds",
+			"Number of seconds since 1970 of the last successful collector refresh.",
+			[]string{"collector"}, nil),
+		cacheAgeDesc: prometheus.NewDesc("paperless_collector_cache_age_seconds",
+			"Age of the cached collector snapshot in seconds.",
+			[]string{"collector"}, nil),
+		staleDesc: prometheus.NewDesc("paperless_collector_stale",
+			"Whether the most recent collector refresh failed and older data is reported.",
+			[]string{"collector"}, nil),
+	}
+}
+
+func (m *cachedMember) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.lastSuccessDesc
+	ch <- m.cacheAgeDesc
+	ch <- m.staleDesc
+
+	m.member.Describe(ch)
+}
+
+// refresh collects a new snapshot from the wrapped member. The previous
+// snapshot is retained on failure. Warnings aren't part of the snapshot and
+// are passed to the report function instead, including one for a failed
+// refresh. Refreshes aborted by the caller, e.g. because another member of
+// the scrape failed, aren't considered an attempt.
+func (m *cachedMember) refresh(ctx context.Context, report func(*warning)) error {
+	recordResult := m.results.start(m.id)
+
+	if m.timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.timeout)
+		defer cancel()
+	}
+
+	var metrics []prometheus.Metric
+
+	collected := make(chan prometheus.Metric)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for i := range collected {
+			if w, ok := i.(*warning); ok && w != nil {
+				report(w)
+				continue
+			}
+
+			metrics = append(metrics, i)
+		}
+	}()
+
+	err := m.member.Collect(ctx, collected)
+
+	close(collected)
+	<-done
+
+	if err != nil && errors.Is(err, context.Canceled) && ctx.Err() != nil {
+		return err
+	}
+
+	m.mu.Lock()
+
+	m.lastAttempt = m.now()
+	m.lastErr = err
+
+	if err == nil {
+		m.metrics = metrics
+		m.lastSuccess = m.now()
+	}
+
+	m.mu.Unlock()
+
+	recordResult(err)
+
+	if err != nil {
+		report(newWarning(warningCategoryCollectorRefresh,
+			fmt.Errorf("collector %s: %w", m.id, err)))
+	}
+
+	return err
+}
+
+// refreshIfOutdated refreshes the snapshot if the last refresh attempt is
+// older than the minimum interval. Failed attempts are not retried within the
+// interval. Failures are only returned if there's no previous snapshot to
+// report as stale instead, with the exception of timeouts which are never
+// treated as errors.
+func (m *cachedMember) refreshIfOutdated(ctx context.Context, report func(*warning)) error {
+	m.refreshMu.Lock()
+	defer m.refreshMu.Unlock()
+
+	m.mu.Lock()
+	fresh := !m.lastAttempt.IsZero() && m.now().Sub(m.lastAttempt) < m.interval
+	m.mu.Unlock()
+
+	if fresh {
+		return nil
+	}
+
+	err := m.refresh(ctx, report)
+	if err == nil || errors.Is(err, context.DeadlineExceeded) {
+		return nil
+	}
+
+	m.mu.Lock()
+	haveSnapshot := !m.lastSuccess.IsZero()
+	m.mu.Unlock()
+
+	if haveSnapshot && ctx.Err() == nil {
+		return nil
+	}
+
+	return err
+}
+
+// run refreshes the snapshot at the configured interval until the context is
+// cancelled. Warnings are passed to the report function.
+func (m *cachedMember) run(ctx context.Context, report func(*warning)) {
+	if !m.background {
+		return
+	}
+
+	if m.logger != nil {
+		ctx = withLogger(ctx, m.logger)
+	}
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		m.refresh(ctx, func(w *warning) {
+			if ctx.Err() == nil {
+				report(w)
+			}
+		})
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *cachedMember) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	if !m.background {
+		if err := m.refreshIfOutdated(ctx, func(w *warning) { ch <- w }); err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var stale float64
+
+	if m.lastErr != nil {
+		stale = 1
+	}
+
+	var lastSuccess float64
+
+	if !m.lastSuccess.IsZero() {
+		lastSuccess = float64(m.lastSuccess.UnixMilli()) / 1000
+
+		ch <- prometheus.MustNewConstMetric(m.cacheAgeDesc, prometheus.GaugeValue,
+			m.now().Sub(m.lastSuccess).Seconds(), m.id)
+	}
+
+	ch <- prometheus.MustNewConstMetric(m.lastSuccessDesc, prometheus.GaugeValue,
+		lastSuccess, m.id)
+	ch <- prometheus.MustNewConstMetric(m.staleDesc, prometheus.GaugeValue,
+		stale, m.id)
+
+	for _, i := range m.metrics {
+		ch <- i
+	}
+
+	return nil
+}
@@ -0,0 +1,413 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hansmi/paperhooks/pkg/client"
+	"github.com/hansmi/prometheus-paperless-exporter/internal/testutil"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCachedMember(t *testing.T) {
+	errTest := errors.New("test error")
+
+	cl := fakeTagClient{
+		items: []client.Tag{
+			{ID: 338, Name: "first", DocumentCount: 13},
+		},
+	}
+
+	now := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	m := newCachedMember("tag", newTagCollector(&cl), time.Minute)
+	m.background = true
+	m.now = func() time.Time { return now }
+
+	c := newMultiCollectorForTest(t, m)
+
+	report := func(w *warning) {
+		c.reportWarning(context.Background(), w.withCollector("tag"))
+	}
+
+	testutil.CollectAndCompare(t, c, `
+# HELP paperless_collector_last_success_timestamp_seconds Number of seconds since 1970 of the last successful collector refresh.
+# TYPE paperless_collector_last_success_timestamp_seconds gauge
+paperless_collector_last_success_timestamp_seconds{collector="tag"} 0
+# HELP paperless_collector_stale Whether the most recent collector refresh failed and older data is reported.
+# TYPE paperless_collector_stale gauge
+paperless_collector_stale{collector="tag"} 0
+# HELP paperless_warnings_total Number of warnings generated while scraping metrics.
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
+paperless_warnings_total{category="unspecified"} 0
+`)
+
+	if err := m.refresh(context.Background(), report); err != nil {
+		t.Errorf("refresh() failed: %v", err)
+	}
+
+	now = now.Add(15 * time.Second)
+
+	testutil.CollectAndCompare(t, c, `
+# HELP paperless_collector_cache_age_seconds Age of the cached collector snapshot in seconds.
+# TYPE paperless_collector_cache_age_seconds gauge
+paperless_collector_cache_age_seconds{collector="tag"} 15
+# HELP paperless_collector_last_success_timestamp_seconds Number of seconds since 1970 of the last successful collector refresh.
+# TYPE paperless_collector_last_success_timestamp_seconds gauge
+paperless_collector_last_success_timestamp_seconds{collector="tag"} 1.5778368e+09
+# HELP paperless_tag_document_count Number of documents associated with a tag.
+# TYPE paperless_tag_document_count gauge
+paperless_tag_document_count{id="338"} 13
+# HELP paperless_warnings_total Number of warnings generated while scraping metrics.
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
+paperless_warnings_total{category="unspecified"} 0
+`,
+		"paperless_collector_cache_age_seconds",
+		"paperless_collector_last_success_timestamp_seconds",
+		"paperless_tag_document_count",
+		"paperless_warnings_total",
+	)
+
+	// A failed refresh keeps the previous snapshot.
+	cl.items = nil
+	cl.err = errTest
+
+	if diff := cmp.Diff(errTest, m.refresh(context.Background(), report), cmpopts.EquateErrors()); diff != "" {
+		t.Errorf("refresh() error diff (-want +got):\n%s", diff)
+	}
+
+	// The failed refresh is reported once regardless of the number of
+	// scrapes.
+	for range 3 {
+		testutil.CollectAndCompare(t, c, `
+# HELP paperless_collector_stale Whether the most recent collector refresh failed and older data is reported.
+# TYPE paperless_collector_stale gauge
+paperless_collector_stale{collector="tag"} 1
+# HELP paperless_tag_document_count Number of documents associated with a tag.
+# TYPE paperless_tag_document_count gauge
+paperless_tag_document_count{id="338"} 13
+# HELP paperless_warnings_total Number of warnings generated while scraping metrics.
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 1
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
+paperless_warnings_total{category="unspecified"} 0
+`,
+			"paperless_collector_stale",
+			"paperless_tag_document_count",
+			"paperless_warnings_total",
+		)
+	}
+}
+
+func TestCachedMemberRun(t *testing.T) {
+	cl := fakeTagClient{
+		items: []client.Tag{
+			{ID: 8463},
+		},
+	}
+
+	m := newCachedMember("tag", newTagCollector(&cl), time.Hour)
+	m.background = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	c := newMultiCollectorForTest(t, m)
+	c.Start(ctx)
+
+	// The first refresh happens immediately.
+	for {
+		m.mu.Lock()
+		count := len(m.metrics)
+		m.mu.Unlock()
+
+		if count > 0 {
+			break
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	testutil.CollectAndCompare(t, c, `
+# HELP paperless_tag_inbox Whether the tag is marked as an inbox tag.
+# TYPE paperless_tag_inbox gauge
+paperless_tag_inbox{id="8463"} 0
+`, "paperless_tag_inbox")
+}
+
+type blockingMember struct {
+	Member
+
+	block bool
+	calls int
+}
+
+func (m *blockingMember) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	m.calls++
+
+	if m.block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	return m.Member.Collect(ctx, ch)
+}
+
+func TestCachedMemberOnScrape(t *testing.T) {
+	cl := fakeTagClient{
+		items: []client.Tag{
+			{ID: 338, DocumentCount: 13},
+		},
+	}
+
+	now := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	bm := &blockingMember{Member: newTagCollector(&cl)}
+
+	m := newCachedMember("tag", bm, time.Minute)
+	m.timeout = 10 * time.Millisecond
+	m.now = func() time.Time { return now }
+
+	c := newMultiCollectorForTest(t, m)
+
+	wantCount := func(count int) string {
+		return fmt.Sprintf(`
+# HELP paperless_tag_document_count Number of documents associated with a tag.
+# TYPE paperless_tag_document_count gauge
+paperless_tag_document_count{id="338"} %d
+`, count)
+	}
+
+	testutil.CollectAndCompare(t, c, wantCount(13), "paperless_tag_document_count")
+
+	// Served from the cache within the refresh interval.
+	cl.items[0].DocumentCount = 20
+	now = now.Add(30 * time.Second)
+
+	testutil.CollectAndCompare(t, c, wantCount(13), "paperless_tag_document_count")
+
+	now = now.Add(time.Minute)
+
+	testutil.CollectAndCompare(t, c, wantCount(20), "paperless_tag_document_count")
+
+	// Timeouts report stale data.
+	cl.items[0].DocumentCount = 30
+	now = now.Add(time.Minute)
+	bm.block = true
+
+	testutil.CollectAndCompare(t, c, `
+# HELP paperless_collector_cache_age_seconds Age of the cached collector snapshot in seconds.
+# TYPE paperless_collector_cache_age_seconds gauge
+paperless_collector_cache_age_seconds{collector="tag"} 60
+# HELP paperless_collector_stale Whether the most recent collector refresh failed and older data is reported.
+# TYPE paperless_collector_stale gauge
+paperless_collector_stale{collector="tag"} 1
+# HELP paperless_tag_document_count Number of documents associated with a tag.
+# TYPE paperless_tag_document_count gauge
+paperless_tag_document_count{id="338"} 20
+# HELP paperless_warnings_total Number of warnings generated while scraping metrics.
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 1
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
+paperless_warnings_total{category="unspecified"} 0
+`,
+		"paperless_collector_cache_age_seconds",
+		"paperless_collector_stale",
+		"paperless_tag_document_count",
+		"paperless_warnings_total",
+	)
+
+	// Failed refreshes are not retried within the refresh interval.
+	calls := bm.calls
+	now = now.Add(30 * time.Second)
+
+	testutil.CollectAndCompare(t, c, wantCount(20), "paperless_tag_document_count")
+
+	if diff := cmp.Diff(calls, bm.calls); diff != "" {
+		t.Errorf("Member collections diff (-want +got):\n%s", diff)
+	}
+
+	// Other errors report stale data as well.
+	bm.block = false
+	cl.err = errors.New("test error")
+	now = now.Add(time.Minute)
+
+	testutil.CollectAndCompare(t, c, `
+# HELP paperless_collector_stale Whether the most recent collector refresh failed and older data is reported.
+# TYPE paperless_collector_stale gauge
+paperless_collector_stale{collector="tag"} 1
+# HELP paperless_tag_document_count Number of documents associated with a tag.
+# TYPE paperless_tag_document_count gauge
+paperless_tag_document_count{id="338"} 20
+# HELP paperless_warnings_total Number of warnings generated while scraping metrics.
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 2
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
+paperless_warnings_total{category="unspecified"} 0
+`,
+		"paperless_collector_stale",
+		"paperless_tag_document_count",
+		"paperless_warnings_total",
+	)
+}
+
+func TestCachedMemberWithoutSnapshot(t *testing.T) {
+	errTest := errors.New("test error")
+
+	m := newCachedMember("tag", newTagCollector(&fakeTagClient{err: errTest}), time.Minute)
+
+	// Errors fail the scrape without a previous snapshot.
+	if diff := cmp.Diff(errTest, m.Collect(context.Background(), testutil.DiscardMetrics(t)), cmpopts.EquateErrors()); diff != "" {
+		t.Errorf("Collect() error diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestCachedMemberFailingSibling(t *testing.T) {
+	cl := fakeTagClient{
+		items: []client.Tag{
+			{ID: 338, DocumentCount: 13},
+		},
+	}
+
+	gcl := fakeGroupClient{err: errors.New("test error")}
+
+	now := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	bm := &blockingMember{Member: newTagCollector(&cl), block: true}
+
+	m := newCachedMember("tag", bm, 5*time.Minute)
+	m.now = func() time.Time { return now }
+
+	c := newMultiCollectorForTest(t, newGroupCollector(&gcl))
+	c.members = append(c.members, m)
+
+	// The cached member is cancelled due to the failing group collector.
+	if err := c.collectWithWarnings(context.Background(), testutil.DiscardMetrics(t)); err == nil {
+		t.Errorf("collectWithWarnings() succeeded, want error")
+	}
+
+	// The aborted refresh is neither an attempt nor a failure.
+	bm.block = false
+	gcl.err = nil
+	now = now.Add(time.Second)
+
+	testutil.CollectAndCompare(t, c, `
+# HELP paperless_collector_stale Whether the most recent collector refresh failed and older data is reported.
+# TYPE paperless_collector_stale gauge
+paperless_collector_stale{collector="tag"} 0
+# HELP paperless_tag_document_count Number of documents associated with a tag.
+# TYPE paperless_tag_document_count gauge
+paperless_tag_document_count{id="338"} 13
+# HELP paperless_warnings_total Number of warnings generated while scraping metrics.
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
+paperless_warnings_total{category="unspecified"} 0
+`,
+		"paperless_collector_stale",
+		"paperless_tag_document_count",
+		"paperless_warnings_total",
+	)
+}
+
+func TestCachedMemberWarningsCountedOnce(t *testing.T) {
+	cl := fakeTagClient{
+		items: []client.Tag{
+			{ID: 338, Name: "invalid\xff"},
+		},
+	}
+
+	now := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	m := newCachedMember("tag", newTagCollector(&cl), time.Minute)
+	m.now = func() time.Time { return now }
+
+	c := newMultiCollectorForTest(t, m)
+
+	want := func(count int) string {
+		return fmt.Sprintf(`
+# HELP paperless_warnings_total Number of warnings generated while scraping metrics.
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} %d
+paperless_warnings_total{category="timeout"} 0
+paperless_warnings_total{category="unspecified"} 0
+`, count)
+	}
+
+	// Scrapes served from the snapshot don't repeat its warnings.
+	for range 6 {
+		testutil.CollectAndCompare(t, c, want(1), "paperless_warnings_total")
+	}
+
+	if got := len(c.RecentWarnings()); got != 1 {
+		t.Errorf("Got %d recent warnings, want 1", got)
+	}
+
+	// Refreshes report warnings anew.
+	now = now.Add(time.Minute)
+
+	testutil.CollectAndCompare(t, c, want(2), "paperless_warnings_total")
+}
+
+func TestCachedMemberRunWarnings(t *testing.T) {
+	cl := fakeTagClient{
+		err: errors.New("test error"),
+	}
+
+	m := newCachedMember("tag", newTagCollector(&cl), time.Hour)
+	m.background = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	c := newMultiCollectorForTest(t, m)
+	c.ids = []string{"tag"}
+	c.Start(ctx)
+
+	// Wait for the first refresh.
+	for len(c.RecentWarnings()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	for range 3 {
+		testutil.CollectAndCompare(t, c, `
+# HELP paperless_collector_stale Whether the most recent collector refresh failed and older data is reported.
+# TYPE paperless_collector_stale gauge
+paperless_collector_stale{collector="tag"} 1
+# HELP paperless_warnings_total Number of warnings generated while scraping metrics.
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 1
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
+paperless_warnings_total{category="unspecified"} 0
+`, "paperless_collector_stale", "paperless_warnings_total")
+	}
+
+	if diff := cmp.Diff([]string{"tag"}, []string{c.RecentWarnings()[0].Collector}); diff != "" {
+		t.Errorf("Warning collector diff (-want +got):\n%s", diff)
+	}
+}
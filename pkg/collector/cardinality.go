@@ -0,0 +1,63 @@
+package collector
+
+import (
+	"cmp"
+	"context"
+	"slices"
+)
+
+// Value of the "id" label of the aggregate reported for objects exceeding the
+// cardinality limit.
+const foldedObjectID = "other"
+
+// cardinalityLimiter is implemented by collectors reporting series per object
+// which support limiting the number of objects reported individually.
+type cardinalityLimiter interface {
+	// setCardinalityLimit restricts the number of objects reported
+	// individually. Zero disables the limit.
+	setCardinalityLimit(int)
+}
+
+// SupportsCardinalityLimit reports whether the collector with the given ID
+// supports [MemberOptions.CardinalityLimit].
+func SupportsCardinalityLimit(id string) bool {
+	info, ok := Lookup(id)
+
+	return ok && info.cardinalityLimit
+}
+
+// collectTopObjects fetches all objects of a listing and passes them to emit.
+// With a positive limit only the objects with the highest document count are
+// passed to emit and the remainder, possibly empty, is passed to fold. Objects
+// with the same document count retain their listing order.
+func collectTopObjects[T any](ctx context.Context, fetch listPageFunc[T], limit int, docCount func(T) int64, emit func(T), fold func([]T)) error {
+	if limit < 1 {
+		return fetchAllPages(ctx, fetch, func(_ context.Context, item T) error {
+			emit(item)
+			return nil
+		})
+	}
+
+	var items []T
+
+	if err := fetchAllPages(ctx, fetch, func(_ context.Context, item T) error {
+		items = append(items, item)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	slices.SortStableFunc(items, func(a, b T) int {
+		return cmp.Compare(docCount(b), docCount(a))
+	})
+
+	count := min(limit, len(items))
+
+	for _, item := range items[:count] {
+		emit(item)
+	}
+
+	fold(items[count:])
+
+	return nil
+}
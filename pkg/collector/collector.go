@@ -0,0 +1,182 @@
+// Package collector implements Prometheus collectors for the metrics of a
+// Paperless-ngx instance.
+package collector
+
+import (
+	"cmp"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/hansmi/paperhooks/pkg/client"
+	"github.com/hansmi/prometheus-paperless-exporter/pkg/relabel"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MemberOptions contains settings for an individual collector.
+type MemberOptions struct {
+	// Minimum duration between refreshes. Scrapes within the interval are
+	// served from cached data.
+	RefreshInterval time.Duration
+
+	// Timeout for a refresh. On expiry the previous results are reported as
+	// stale.
+	Timeout time.Duration
+
+	// Redaction policies keyed by label name.
+	Redact map[string]*RedactionPolicy
+
+	// Maximum number of objects reported individually. Zero disables the
+	// limit.
+	CardinalityLimit int
+}
+
+// Options contains settings for [New].
+type Options struct {
+	// Impose a timeout on collection if non-zero.
+	Timeout time.Duration
+
+	// Safety margin subtracted from the scrape timeout announced by
+	// Prometheus.
+	TimeoutOffset time.Duration
+
+	// Collectors to enable instead of the default ones.
+	EnabledIDs []string
+
+	// Enable (true) or disable (false) individual collectors after the
+	// enabled IDs have been determined.
+	Overrides map[string]bool
+
+	// Refresh members in the background at the given interval and serve
+	// scrapes from cached snapshots if non-zero. The background loops are
+	// launched by [Collector.Start].
+	BackgroundInterval time.Duration
+
+	// Per-collector settings keyed by collector ID.
+	Members map[string]MemberOptions
+
+	// Counter of scrapes collapsed into a concurrent collection run, if
+	// non-nil.
+	CollapsedScrapes prometheus.Counter
+
+	// Relabeling rules applied to collected metrics before exposition.
+	RelabelConfigs []*relabel.Config
+
+	// Logger for collection warnings and errors. Members receive it via
+	// [Logger]. Defaults to [slog.Default].
+	Logger *slog.Logger
+
+	// Provider for spans of scrapes, members and page fetches. Defaults to
+	// the global provider.
+	TracerProvider trace.TracerProvider
+
+	// Collector replaced by the new one, if any, e.g. on a configuration
+	// reload. Cumulative warning counts, recent warnings and the status of
+	// members are carried over.
+	Previous *Collector
+}
+
+// resolveIDs returns the sorted IDs of all enabled collectors.
+func (opts Options) resolveIDs() ([]string, error) {
+	if err := CheckIDs(opts.EnabledIDs); err != nil {
+		return nil, err
+	}
+
+	if err := CheckIDs(slices.Collect(maps.Keys(opts.Overrides))); err != nil {
+		return nil, err
+	}
+
+	enabled := map[string]bool{}
+
+	if len(opts.EnabledIDs) == 0 {
+		for _, id := range IDs() {
+			info, _ := Lookup(id)
+			enabled[id] = info.DefaultEnabled
+		}
+	} else {
+		for _, id := range opts.EnabledIDs {
+			enabled[id] = true
+		}
+	}
+
+	maps.Copy(enabled, opts.Overrides)
+
+	maps.DeleteFunc(enabled, func(_ string, value bool) bool {
+		return !value
+	})
+
+	return slices.Sorted(maps.Keys(enabled)), nil
+}
+
+// New builds a collector for the Paperless instance accessed via the given
+// client. Background refreshes, if any, must be launched via
+// [Collector.Start].
+func New(cl *client.Client, opts Options) (*Collector, error) {
+	ids, err := opts.resolveIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	logger := cmp.Or(opts.Logger, slog.Default())
+
+	var members []Member
+
+	for _, id := range ids {
+		info, _ := Lookup(id)
+
+		m := info.New(cl)
+		mo := opts.Members[id]
+
+		if mo.CardinalityLimit > 0 {
+			l, ok := m.(cardinalityLimiter)
+			if !ok {
+				return nil, fmt.Errorf("collector %s does not support a cardinality limit", id)
+			}
+
+			l.setCardinalityLimit(mo.CardinalityLimit)
+		}
+
+		if len(mo.Redact) > 0 {
+			m = newRedactingMember(m, mo.Redact)
+		}
+
+		if opts.BackgroundInterval > 0 {
+			cm := newCachedMember(id, m, cmp.Or(mo.RefreshInterval, opts.BackgroundInterval))
+			cm.timeout = cmp.Or(mo.Timeout, opts.Timeout)
+			cm.background = true
+			cm.logger = logger.With("collector", id)
+			m = cm
+		} else if mo.RefreshInterval > 0 || mo.Timeout > 0 {
+			cm := newCachedMember(id, m, mo.RefreshInterval)
+			cm.timeout = mo.Timeout
+			m = cm
+		}
+
+		members = append(members, m)
+	}
+
+	c := newMultiCollector(members...)
+	c.ids = ids
+	c.timeoutOffset = opts.TimeoutOffset
+	c.collapsed = opts.CollapsedScrapes
+	c.relabelConfigs = opts.RelabelConfigs
+	c.logger = logger
+
+	if opts.Previous != nil {
+		c.inheritHistory(opts.Previous)
+	}
+
+	if opts.TracerProvider != nil {
+		c.tracer = opts.TracerProvider.Tracer(tracerName)
+	}
+
+	if opts.BackgroundInterval == 0 {
+		// Cached members apply the timeout to their own refreshes.
+		c.timeout = opts.Timeout
+	}
+
+	return c, nil
+}
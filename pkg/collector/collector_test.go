@@ -1,8 +1,11 @@
-package main
+package collector
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"mime"
 	"net/http"
 	"net/http/httptest"
@@ -128,7 +131,11 @@ paperless_users 20
 # TYPE paperless_documents gauge
 paperless_documents 30
 # HELP paperless_warnings_total Number of warnings generated while scraping metrics.
-# TYPE paperless_warnings_total gauge
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
 paperless_warnings_total{category="unspecified"} 0
 `)
 
@@ -140,13 +147,14 @@ paperless_remote_version_update_available{version="v2.14.7"} 1
 `)
 			}
 
-			c, err := newCollector(collectorOptions{
-				client:              cl,
-				timeout:             time.Minute,
-				enableRemoteNetwork: enableRemoteNetwork,
+			c, err := New(cl, Options{
+				Timeout: time.Minute,
+				Overrides: map[string]bool{
+					"remote_version": enableRemoteNetwork,
+				},
 			})
 			if err != nil {
-				t.Errorf("newCollector() failed: %v", err)
+				t.Errorf("New() failed: %v", err)
 			}
 
 			testutil.CollectAndCompare(t, c, want.String())
@@ -156,35 +164,54 @@ paperless_remote_version_update_available{version="v2.14.7"} 1
 
 func TestCollectorError(t *testing.T) {
 	for _, tc := range []struct {
-		name                string
-		enableRemoteNetwork bool
-		enabled             []string
-		wantErr             error
+		name      string
+		overrides map[string]bool
+		enabled   []string
+		members   map[string]MemberOptions
+		wantErr   error
 	}{
 		{name: "default"},
 		{
-			name:                "default with remote",
-			enableRemoteNetwork: true,
+			name: "default with remote",
+			overrides: map[string]bool{
+				"remote_version": true,
+			},
 		},
 		{
 			name:    "unknown",
 			enabled: []string{"bad", "", "foo"},
 			wantErr: cmpopts.AnyError,
 		},
+		{
+			name: "unknown override",
+			overrides: map[string]bool{
+				"bad": true,
+			},
+			wantErr: cmpopts.AnyError,
+		},
 		{
 			name:    "remote version only",
 			enabled: []string{"remote_version"},
 		},
 		{
-			name:                "enabled remote version only",
-			enableRemoteNetwork: true,
-			enabled:             []string{"remote_version"},
+			name: "cardinality limit",
+			members: map[string]MemberOptions{
+				"tag": {CardinalityLimit: 10},
+			},
+		},
+		{
+			name: "cardinality limit unsupported",
+			members: map[string]MemberOptions{
+				"status": {CardinalityLimit: 10},
+			},
+			wantErr: cmpopts.AnyError,
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			_, err := newCollector(collectorOptions{
-				enableRemoteNetwork: tc.enableRemoteNetwork,
-				enabledIDs:          tc.enabled,
+			_, err := New(nil, Options{
+				Overrides:  tc.overrides,
+				EnabledIDs: tc.enabled,
+				Members:    tc.members,
 			})
 
 			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
@@ -193,3 +220,107 @@ func TestCollectorError(t *testing.T) {
 		})
 	}
 }
+
+func TestCollectorResolveIDs(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts Options
+		want []string
+	}{
+		{
+			name: "default",
+			want: []string{
+				"correspondent", "document", "document_type", "group", "log",
+				"statistics", "status", "storage_path", "tag", "task", "user",
+			},
+		},
+		{
+			name: "overrides",
+			opts: Options{
+				Overrides: map[string]bool{
+					"remote_version": true,
+					"log":            false,
+					"tag":            false,
+					"task":           true,
+				},
+			},
+			want: []string{
+				"correspondent", "document", "document_type", "group",
+				"remote_version", "statistics", "status", "storage_path", "task",
+				"user",
+			},
+		},
+		{
+			name: "explicit",
+			opts: Options{
+				EnabledIDs: []string{"tag", "remote_version", "tag"},
+			},
+			want: []string{"remote_version", "tag"},
+		},
+		{
+			name: "explicit with overrides",
+			opts: Options{
+				EnabledIDs: []string{"tag", "status"},
+				Overrides: map[string]bool{
+					"status": false,
+					"user":   true,
+				},
+			},
+			want: []string{"tag", "user"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.opts.resolveIDs()
+			if err != nil {
+				t.Errorf("resolveIDs() failed: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("IDs diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCollectorPrevious(t *testing.T) {
+	opts := Options{
+		EnabledIDs: []string{"group", "tag"},
+		Members: map[string]MemberOptions{
+			"tag": {RefreshInterval: time.Minute},
+		},
+		Logger: slog.New(slog.DiscardHandler),
+	}
+
+	prev, err := New(nil, opts)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	prev.reportWarning(context.Background(), newWarning(warningCategoryInvalidMetric, errors.New("test warning")))
+	prev.results.start("group")(errors.New("test error"))
+
+	opts.Previous = prev
+
+	c, err := New(nil, opts)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	if got := len(c.RecentWarnings()); got != 1 {
+		t.Errorf("Got %d recent warnings, want 1", got)
+	}
+
+	if diff := cmp.Diff("test error", c.Status()[0].LastError); diff != "" {
+		t.Errorf("Last error diff (-want +got):\n%s", diff)
+	}
+
+	// Cached members record their refreshes in the carried over status.
+	if cm := c.members[1].(*cachedMember); cm.results != c.results {
+		t.Errorf("Cached member records results in %p, want %p", cm.results, c.results)
+	}
+
+	// Cumulative warning counts continue.
+	if c.warnings != prev.warnings {
+		t.Errorf("Warning recorder %p differs from previous %p", c.warnings, prev.warnings)
+	}
+}
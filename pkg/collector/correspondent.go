@@ -0,0 +1,111 @@
+package collector
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/hansmi/paperhooks/pkg/client"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type correspondentClient interface {
+	ListCorrespondents(context.Context, client.ListCorrespondentsOptions) ([]client.Correspondent, *client.Response, error)
+}
+
+type correspondentCollector struct {
+	cl correspondentClient
+
+	// Maximum number of correspondents reported individually. Zero disables
+	// the limit.
+	limit int
+
+	infoDesc               *prometheus.Desc
+	docCountDesc           *prometheus.Desc
+	lastCorrespondenceDesc *prometheus.Desc
+	foldedDesc             *prometheus.Desc
+}
+
+func newCorrespondentCollector(cl correspondentClient) *correspondentCollector {
+	return &correspondentCollector{
+		cl: cl,
+
+		infoDesc: prometheus.NewDesc("paperless_correspondent_info",
+			"Static information about a correspondent.",
+			[]string{"id", "name", "slug"}, nil),
+		docCountDesc: prometheus.NewDesc("paperless_correspondent_document_count",
+			"Number of documents associated with a correspondent.",
+			[]string{"id"}, nil),
+		lastCorrespondenceDesc: prometheus.NewDesc("paperless_correspondent_last_correspondence_timestamp_seconds",
+			"Number of seconds since 1970 of the most recent correspondence.",
+			[]string{"id"}, nil),
+		foldedDesc: prometheus.NewDesc("paperless_correspondent_folded_objects",
+			`Number of correspondents folded into the id="other" aggregate by the cardinality limit.`,
+			nil, nil),
+	}
+}
+
+func (c *correspondentCollector) setCardinalityLimit(limit int) {
+	c.limit = limit
+}
+
+func (c *correspondentCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.infoDesc
+	ch <- c.docCountDesc
+	ch <- c.lastCorrespondenceDesc
+	ch <- c.foldedDesc
+}
+
+func (c *correspondentCollector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	b := newMetricBuilder(ch)
+
+	var opts client.ListCorrespondentsOptions
+
+	opts.Ordering.Field = "name"
+
+	fetch := func(ctx context.Context, page *client.PageOptions) ([]client.Correspondent, *client.Response, error) {
+		pageOpts := opts
+		pageOpts.Page = page
+
+		return c.cl.ListCorrespondents(ctx, pageOpts)
+	}
+
+	docCount := func(correspondent client.Correspondent) int64 {
+		return correspondent.DocumentCount
+	}
+
+	emit := func(correspondent client.Correspondent) {
+		id := strconv.FormatInt(correspondent.ID, 10)
+
+		b.gauge(c.infoDesc, 1,
+			id,
+			correspondent.Name,
+			correspondent.Slug,
+		)
+
+		b.gauge(c.docCountDesc, float64(correspondent.DocumentCount), id)
+
+		b.gauge(c.lastCorrespondenceDesc, optionalTimestamp(correspondent.LastCorrespondence), id)
+	}
+
+	fold := func(correspondents []client.Correspondent) {
+		b.gauge(c.foldedDesc, float64(len(correspondents)))
+
+		if len(correspondents) == 0 {
+			return
+		}
+
+		var total int64
+		var last float64
+
+		for _, correspondent := range correspondents {
+			total += correspondent.DocumentCount
+			last = max(last, optionalTimestamp(correspondent.LastCorrespondence))
+		}
+
+		b.gauge(c.infoDesc, 1, foldedObjectID, "", "")
+		b.gauge(c.docCountDesc, float64(total), foldedObjectID)
+		b.gauge(c.lastCorrespondenceDesc, last, foldedObjectID)
+	}
+
+	return collectTopObjects(ctx, fetch, c.limit, docCount, emit, fold)
+}
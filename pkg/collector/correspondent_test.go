@@ -1,4 +1,4 @@
-package main
+package collector
 
 import (
 	"context"
@@ -18,14 +18,8 @@ type fakeCorrespondentClient struct {
 	err   error
 }
 
-func (c *fakeCorrespondentClient) ListAllCorrespondents(ctx context.Context, opts client.ListCorrespondentsOptions, handler func(context.Context, client.Correspondent) error) error {
-	for _, i := range c.items {
-		if err := handler(ctx, i); err != nil {
-			return err
-		}
-	}
-
-	return c.err
+func (c *fakeCorrespondentClient) ListCorrespondents(ctx context.Context, opts client.ListCorrespondentsOptions) ([]client.Correspondent, *client.Response, error) {
+	return fakeListPage(c.items, c.err, opts.Page)
 }
 
 func TestCorrespondent(t *testing.T) {
@@ -60,7 +54,7 @@ func TestCorrespondent(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			c := newCorrespondentCollector(&tc.cl)
 
-			err := c.collect(context.Background(), testutil.DiscardMetrics(t))
+			err := c.Collect(context.Background(), testutil.DiscardMetrics(t))
 
 			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Errorf("Error diff (-want +got):\n%s", diff)
@@ -76,7 +70,11 @@ func TestCorrespondentCollect(t *testing.T) {
 
 	testutil.CollectAndCompare(t, c, `
 # HELP paperless_warnings_total Number of warnings generated while scraping metrics.
-# TYPE paperless_warnings_total gauge
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
 paperless_warnings_total{category="unspecified"} 0
 `)
 
@@ -108,7 +106,56 @@ paperless_correspondent_last_correspondence_timestamp_seconds{id="15818"} 0
 paperless_correspondent_last_correspondence_timestamp_seconds{id="167"} 1.5619392e+09
 paperless_correspondent_last_correspondence_timestamp_seconds{id="24467"} 0
 # HELP paperless_warnings_total Number of warnings generated while scraping metrics.
-# TYPE paperless_warnings_total gauge
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
+paperless_warnings_total{category="unspecified"} 0
+`)
+}
+
+func TestCorrespondentCollectCardinalityLimit(t *testing.T) {
+	cl := fakeCorrespondentClient{
+		items: []client.Correspondent{
+			{ID: 1, Name: "a@example.com", DocumentCount: 1},
+			{
+				ID:                 2,
+				Name:               "b@example.com",
+				DocumentCount:      1,
+				LastCorrespondence: ref.Ref(time.Date(2019, time.July, 1, 0, 0, 0, 0, time.UTC)),
+			},
+			{ID: 3, Name: "employer", DocumentCount: 121},
+		},
+	}
+
+	cc := newCorrespondentCollector(&cl)
+	cc.setCardinalityLimit(1)
+
+	c := newMultiCollectorForTest(t, cc)
+
+	testutil.CollectAndCompare(t, c, `
+# HELP paperless_correspondent_document_count Number of documents associated with a correspondent.
+# TYPE paperless_correspondent_document_count gauge
+paperless_correspondent_document_count{id="3"} 121
+paperless_correspondent_document_count{id="other"} 2
+# HELP paperless_correspondent_folded_objects Number of correspondents folded into the id="other" aggregate by the cardinality limit.
+# TYPE paperless_correspondent_folded_objects gauge
+paperless_correspondent_folded_objects 2
+# HELP paperless_correspondent_info Static information about a correspondent.
+# TYPE paperless_correspondent_info gauge
+paperless_correspondent_info{id="3",name="employer",slug=""} 1
+paperless_correspondent_info{id="other",name="",slug=""} 1
+# HELP paperless_correspondent_last_correspondence_timestamp_seconds Number of seconds since 1970 of the most recent correspondence.
+# TYPE paperless_correspondent_last_correspondence_timestamp_seconds gauge
+paperless_correspondent_last_correspondence_timestamp_seconds{id="3"} 0
+paperless_correspondent_last_correspondence_timestamp_seconds{id="other"} 1.5619392e+09
+# HELP paperless_warnings_total Number of warnings generated while scraping metrics.
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
 paperless_warnings_total{category="unspecified"} 0
 `)
 }
@@ -1,4 +1,4 @@
-package main
+package collector
 
 import (
 	"context"
@@ -27,11 +27,13 @@ func newDocumentCollector(cl documentClient) *documentCollector {
 	}
 }
 
-func (c *documentCollector) describe(ch chan<- *prometheus.Desc) {
+func (c *documentCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.countDesc
 }
 
-func (c *documentCollector) collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+func (c *documentCollector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	b := newMetricBuilder(ch)
+
 	_, response, err := c.cl.ListDocuments(ctx, client.ListDocumentsOptions{})
 
 	if err != nil {
@@ -39,8 +41,7 @@ func (c *documentCollector) collect(ctx context.Context, ch chan<- prometheus.Me
 	}
 
 	if response.ItemCount != client.ItemCountUnknown {
-		ch <- prometheus.MustNewConstMetric(c.countDesc, prometheus.GaugeValue,
-			float64(response.ItemCount))
+		b.gauge(c.countDesc, float64(response.ItemCount))
 	}
 
 	return nil
@@ -1,4 +1,4 @@
-package main
+package collector
 
 import (
 	"context"
@@ -50,7 +50,7 @@ func TestDocument(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			c := newDocumentCollector(&tc.cl)
 
-			err := c.collect(context.Background(), testutil.DiscardMetrics(t))
+			err := c.Collect(context.Background(), testutil.DiscardMetrics(t))
 
 			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Errorf("Error diff (-want +got):\n%s", diff)
@@ -68,7 +68,11 @@ func TestDocumentCollect(t *testing.T) {
 
 	testutil.CollectAndCompare(t, c, `
 # HELP paperless_warnings_total Number of warnings generated while scraping metrics.
-# TYPE paperless_warnings_total gauge
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
 paperless_warnings_total{category="unspecified"} 0
 `)
 
@@ -79,7 +83,11 @@ paperless_warnings_total{category="unspecified"} 0
 # TYPE paperless_documents gauge
 paperless_documents 11921
 # HELP paperless_warnings_total Number of warnings generated while scraping metrics.
-# TYPE paperless_warnings_total gauge
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
 paperless_warnings_total{category="unspecified"} 0
 `)
 }
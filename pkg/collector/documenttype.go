@@ -0,0 +1,101 @@
+package collector
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/hansmi/paperhooks/pkg/client"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type documentTypeClient interface {
+	ListDocumentTypes(context.Context, client.ListDocumentTypesOptions) ([]client.DocumentType, *client.Response, error)
+}
+
+type documentTypeCollector struct {
+	cl documentTypeClient
+
+	// Maximum number of document types reported individually. Zero disables the
+	// limit.
+	limit int
+
+	infoDesc     *prometheus.Desc
+	docCountDesc *prometheus.Desc
+	foldedDesc   *prometheus.Desc
+}
+
+func newDocumentTypeCollector(cl documentTypeClient) *documentTypeCollector {
+	return &documentTypeCollector{
+		cl: cl,
+
+		infoDesc: prometheus.NewDesc("paperless_document_type_info",
+			"Static information about a document type.",
+			[]string{"id", "name", "slug"}, nil),
+		docCountDesc: prometheus.NewDesc("paperless_document_type_document_count",
+			"Number of documents associated with a document type.",
+			[]string{"id"}, nil),
+		foldedDesc: prometheus.NewDesc("paperless_document_type_folded_objects",
+			`Number of document types folded into the id="other" aggregate by the cardinality limit.`,
+			nil, nil),
+	}
+}
+
+func (c *documentTypeCollector) setCardinalityLimit(limit int) {
+	c.limit = limit
+}
+
+func (c *documentTypeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.infoDesc
+	ch <- c.docCountDesc
+	ch <- c.foldedDesc
+}
+
+func (c *documentTypeCollector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	b := newMetricBuilder(ch)
+
+	var opts client.ListDocumentTypesOptions
+
+	opts.Ordering.Field = "name"
+
+	fetch := func(ctx context.Context, page *client.PageOptions) ([]client.DocumentType, *client.Response, error) {
+		pageOpts := opts
+		pageOpts.Page = page
+
+		return c.cl.ListDocumentTypes(ctx, pageOpts)
+	}
+
+	docCount := func(doctype client.DocumentType) int64 {
+		return doctype.DocumentCount
+	}
+
+	emit := func(doctype client.DocumentType) {
+		id := strconv.FormatInt(doctype.ID, 10)
+
+		b.gauge(c.infoDesc, 1,
+			id,
+			doctype.Name,
+			doctype.Slug,
+		)
+
+		b.gauge(c.docCountDesc, float64(doctype.DocumentCount), id)
+	}
+
+	fold := func(doctypes []client.DocumentType) {
+		b.gauge(c.foldedDesc, float64(len(doctypes)))
+
+		if len(doctypes) == 0 {
+			return
+		}
+
+		var total int64
+
+		for _, doctype := range doctypes {
+			total += doctype.DocumentCount
+		}
+
+		b.gauge(c.infoDesc, 1, foldedObjectID, "", "")
+		b.gauge(c.docCountDesc, float64(total), foldedObjectID)
+	}
+
+	return collectTopObjects(ctx, fetch, c.limit, docCount, emit, fold)
+}
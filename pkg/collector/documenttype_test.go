@@ -1,4 +1,4 @@
-package main
+package collector
 
 import (
 	"context"
@@ -16,14 +16,8 @@ type fakeDocumentTypeClient struct {
 	err   error
 }
 
-func (c *fakeDocumentTypeClient) ListAllDocumentTypes(ctx context.Context, opts client.ListDocumentTypesOptions, handler func(context.Context, client.DocumentType) error) error {
-	for _, i := range c.items {
-		if err := handler(ctx, i); err != nil {
-			return err
-		}
-	}
-
-	return c.err
+func (c *fakeDocumentTypeClient) ListDocumentTypes(ctx context.Context, opts client.ListDocumentTypesOptions) ([]client.DocumentType, *client.Response, error) {
+	return fakeListPage(c.items, c.err, opts.Page)
 }
 
 func TestDocumentType(t *testing.T) {
@@ -58,7 +52,7 @@ func TestDocumentType(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			c := newDocumentTypeCollector(&tc.cl)
 
-			err := c.collect(context.Background(), testutil.DiscardMetrics(t))
+			err := c.Collect(context.Background(), testutil.DiscardMetrics(t))
 
 			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Errorf("Error diff (-want +got):\n%s", diff)
@@ -74,7 +68,11 @@ func TestDocumentTypeCollect(t *testing.T) {
 
 	testutil.CollectAndCompare(t, c, `
 # HELP paperless_warnings_total Number of warnings generated while scraping metrics.
-# TYPE paperless_warnings_total gauge
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
 paperless_warnings_total{category="unspecified"} 0
 `)
 
@@ -93,7 +91,11 @@ paperless_document_type_document_count{id="5558"} 20
 paperless_document_type_info{id="3760",name="Contract",slug="contract"} 1
 paperless_document_type_info{id="5558",name="Purchase order",slug="po"} 1
 # HELP paperless_warnings_total Number of warnings generated while scraping metrics.
-# TYPE paperless_warnings_total gauge
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
 paperless_warnings_total{category="unspecified"} 0
 `)
 }
@@ -1,4 +1,4 @@
-package main
+package collector
 
 import (
 	"context"
@@ -27,11 +27,13 @@ func newGroupCollector(cl groupClient) *groupCollector {
 	}
 }
 
-func (c *groupCollector) describe(ch chan<- *prometheus.Desc) {
+func (c *groupCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.countDesc
 }
 
-func (c *groupCollector) collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+func (c *groupCollector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	b := newMetricBuilder(ch)
+
 	_, response, err := c.cl.ListGroups(ctx, client.ListGroupsOptions{})
 
 	if err != nil {
@@ -39,8 +41,7 @@ func (c *groupCollector) collect(ctx context.Context, ch chan<- prometheus.Metri
 	}
 
 	if response.ItemCount != client.ItemCountUnknown {
-		ch <- prometheus.MustNewConstMetric(c.countDesc, prometheus.GaugeValue,
-			float64(response.ItemCount))
+		b.gauge(c.countDesc, float64(response.ItemCount))
 	}
 
 	return nil
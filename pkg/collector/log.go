@@ -1,11 +1,13 @@
-package main
+package collector
 
 import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"net/http"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -61,11 +63,11 @@ func newLogCollector(cl logClient) *logCollector {
 	}
 }
 
-func (c *logCollector) describe(ch chan<- *prometheus.Desc) {
+func (c *logCollector) Describe(ch chan<- *prometheus.Desc) {
 	c.totalVec.Describe(ch)
 }
 
-func (c *logCollector) collectOne(ctx context.Context, name string) error {
+func (c *logCollector) collectOne(ctx context.Context, name string, ch chan<- prometheus.Metric) error {
 	entries, _, err := c.cl.GetLog(ctx, name)
 	if err != nil {
 		var reqErr *client.RequestError
@@ -81,12 +83,26 @@ func (c *logCollector) collectOne(ctx context.Context, name string) error {
 		return nil
 	}
 
+	// Names of labels with invalid values, reported once per collection.
+	invalid := map[string]struct{}{}
+
 	entryLabels := func(e client.LogEntry) prometheus.Labels {
-		return prometheus.Labels{
+		labels := prometheus.Labels{
 			"name":   name,
 			"module": e.Module,
 			"level":  strings.ToLower(e.Level),
 		}
+
+		// Vectors panic on invalid label values.
+		for k, v := range labels {
+			var modified bool
+
+			if labels[k], modified = sanitizeLabelValue(v); modified {
+				invalid[k] = struct{}{}
+			}
+		}
+
+		return labels
 	}
 
 	c.mu.Lock()
@@ -113,10 +129,15 @@ func (c *logCollector) collectOne(ctx context.Context, name string) error {
 
 	c.seen[name] = newLogPosition(newest)
 
+	for _, i := range slices.Sorted(maps.Keys(invalid)) {
+		ch <- newWarning(warningCategoryInvalidMetric,
+			fmt.Errorf("paperless_log_entries_total: value of label %s is not valid UTF-8", i))
+	}
+
 	return nil
 }
 
-func (c *logCollector) collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+func (c *logCollector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
 	names, _, err := c.cl.ListLogs(ctx)
 	if err != nil {
 		return fmt.Errorf("listing log names: %w", err)
@@ -129,7 +150,7 @@ func (c *logCollector) collect(ctx context.Context, ch chan<- prometheus.Metric)
 		name := name
 
 		g.Go(func() error {
-			if err := c.collectOne(ctx, name); err != nil {
+			if err := c.collectOne(ctx, name, ch); err != nil {
 				return fmt.Errorf("log %s: %w", name, err)
 			}
 
@@ -1,4 +1,4 @@
-package main
+package collector
 
 import (
 	"context"
@@ -102,7 +102,7 @@ func TestLog(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			c := newLogCollector(&tc.cl)
 
-			err := c.collect(context.Background(), testutil.DiscardMetrics(t))
+			err := c.Collect(context.Background(), testutil.DiscardMetrics(t))
 
 			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Errorf("Error diff (-want +got):\n%s", diff)
@@ -120,7 +120,11 @@ func TestLogCollect(t *testing.T) {
 
 	testutil.CollectAndCompare(t, c, `
 # HELP paperless_warnings_total Number of warnings generated while scraping metrics.
-# TYPE paperless_warnings_total gauge
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
 paperless_warnings_total{category="unspecified"} 0
 `)
 
@@ -128,7 +132,11 @@ paperless_warnings_total{category="unspecified"} 0
 
 	testutil.CollectAndCompare(t, c, `
 # HELP paperless_warnings_total Number of warnings generated while scraping metrics.
-# TYPE paperless_warnings_total gauge
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
 paperless_warnings_total{category="unspecified"} 0
 `)
 
@@ -150,7 +158,11 @@ paperless_warnings_total{category="unspecified"} 0
 paperless_log_entries_total{level="",module="storage",name="server"} 1
 paperless_log_entries_total{level="another",module="storage",name="server"} 1
 # HELP paperless_warnings_total Number of warnings generated while scraping metrics.
-# TYPE paperless_warnings_total gauge
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
 paperless_warnings_total{category="unspecified"} 0
 `)
 
@@ -175,7 +187,11 @@ paperless_log_entries_total{level="",module="",name="db"} 1
 paperless_log_entries_total{level="",module="storage",name="server"} 2
 paperless_log_entries_total{level="another",module="storage",name="server"} 1
 # HELP paperless_warnings_total Number of warnings generated while scraping metrics.
-# TYPE paperless_warnings_total gauge
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
 paperless_warnings_total{category="unspecified"} 0
 `)
 
@@ -183,3 +199,38 @@ paperless_warnings_total{category="unspecified"} 0
 		cl.entries = nil
 	}
 }
+
+func TestLogInvalidUTF8(t *testing.T) {
+	cl := fakeLogClient{
+		names: []string{"server"},
+	}
+
+	cl.addEntries("server", []client.LogEntry{
+		{
+			Time:   time.Date(2020, time.March, 1, 0, 0, 0, 0, time.UTC),
+			Module: "bad\xff",
+		},
+		{
+			Time:   time.Date(2020, time.March, 2, 0, 0, 0, 0, time.UTC),
+			Module: "bad\xff",
+		},
+	})
+
+	c := newMultiCollectorForTest(t, newLogCollector(&cl))
+
+	testutil.CollectAndCompare(t, c, `
+# HELP paperless_log_entries_total Best-effort count of log entries.
+# TYPE paperless_log_entries_total counter
+paperless_log_entries_total{level="",module="bad�",name="server"} 2
+# HELP paperless_warning_last_timestamp_seconds Number of seconds since 1970 of the last warning in a category.
+# TYPE paperless_warning_last_timestamp_seconds gauge
+paperless_warning_last_timestamp_seconds{category="invalid_metric"} 1.5778368e+09
+# HELP paperless_warnings_total Number of warnings generated while scraping metrics.
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 1
+paperless_warnings_total{category="timeout"} 0
+paperless_warnings_total{category="unspecified"} 0
+`)
+}
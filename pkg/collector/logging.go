@@ -0,0 +1,134 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Minimum duration between log messages for repeats of the same warning.
+const defaultLogRepeatInterval = 5 * time.Minute
+
+type loggerContextKey struct{}
+
+// withLogger returns a context carrying the given logger.
+func withLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
+}
+
+// Logger returns the logger for the member collecting with the given context.
+// Messages include the collector ID as the "collector" attribute. The default
+// logger is returned outside of collections.
+func Logger(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerContextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+
+	return slog.Default()
+}
+
+// endpointFromError returns the URL path of the first failed HTTP request in
+// the error chain, if any.
+func endpointFromError(err error) string {
+	var urlErr *url.Error
+
+	if errors.As(err, &urlErr) {
+		if u, parseErr := url.Parse(urlErr.URL); parseErr == nil {
+			return u.Path
+		}
+	}
+
+	return ""
+}
+
+type repeatState struct {
+	last       time.Time
+	suppressed int
+}
+
+// repeatLimiter restricts how often identical messages are logged.
+type repeatLimiter struct {
+	interval time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	lastPrune time.Time
+	entries   map[string]*repeatState
+}
+
+func newRepeatLimiter(interval time.Duration) *repeatLimiter {
+	return &repeatLimiter{
+		interval: interval,
+		now:      time.Now,
+		entries:  map[string]*repeatState{},
+	}
+}
+
+// allow reports whether a message with the given key should be logged. The
+// number of repeats suppressed since the last logged message is returned
+// alongside.
+func (l *repeatLimiter) allow(key string) (bool, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	if now.Sub(l.lastPrune) >= l.interval {
+		for k, s := range l.entries {
+			// Entries with suppressed repeats are retained a while longer
+			// to report the count with the next message.
+			if age := now.Sub(s.last); (age >= l.interval && s.suppressed == 0) || age >= 2*l.interval {
+				delete(l.entries, k)
+			}
+		}
+
+		l.lastPrune = now
+	}
+
+	s := l.entries[key]
+
+	if s == nil {
+		l.entries[key] = &repeatState{last: now}
+		return true, 0
+	}
+
+	if now.Sub(s.last) < l.interval {
+		s.suppressed++
+		return false, 0
+	}
+
+	suppressed := s.suppressed
+
+	s.last = now
+	s.suppressed = 0
+
+	return true, suppressed
+}
+
+// logLimited logs a message unless an identical one, including all
+// attributes, was logged recently.
+func logLimited(ctx context.Context, logger *slog.Logger, limiter *repeatLimiter, level slog.Level, msg string, attrs ...slog.Attr) {
+	var key strings.Builder
+
+	key.WriteString(msg)
+
+	for _, a := range attrs {
+		key.WriteByte(0xff)
+		key.WriteString(a.String())
+	}
+
+	ok, suppressed := limiter.allow(key.String())
+	if !ok {
+		return
+	}
+
+	if suppressed > 0 {
+		attrs = append(attrs, slog.Int("suppressed_repeats", suppressed))
+	}
+
+	logger.LogAttrs(ctx, level, msg, attrs...)
+}
@@ -0,0 +1,166 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hansmi/prometheus-paperless-exporter/internal/testutil"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// warningMember reports the given warnings and logs a message via the
+// context logger.
+type warningMember struct {
+	warnings []*warning
+}
+
+func (*warningMember) Describe(chan<- *prometheus.Desc) {}
+
+func (m *warningMember) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	Logger(ctx).Info("Collecting")
+
+	for _, w := range m.warnings {
+		ch <- w
+	}
+
+	return nil
+}
+
+func decodeLogLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var result []map[string]any
+
+	dec := json.NewDecoder(buf)
+
+	for dec.More() {
+		var line map[string]any
+
+		if err := dec.Decode(&line); err != nil {
+			t.Fatalf("Decoding log line failed: %v", err)
+		}
+
+		delete(line, slog.TimeKey)
+
+		result = append(result, line)
+	}
+
+	return result
+}
+
+func TestCollectorLogging(t *testing.T) {
+	var buf bytes.Buffer
+
+	c := newMultiCollector(&warningMember{
+		warnings: []*warning{
+			newWarning(warningCategoryInvalidMetric, errors.New("bad label")),
+			newWarning(warningCategoryInvalidMetric, errors.New("bad label")),
+			newWarning(warningCategoryGetRemoteVersion, &url.Error{
+				Op:  "Get",
+				URL: "http://localhost/api/remote_version/?x=1",
+				Err: errors.New("refused"),
+			}),
+		},
+	})
+	c.ids = []string{"custom"}
+	c.logger = slog.New(slog.NewJSONHandler(&buf, nil))
+	c.warnings.now = func() time.Time { return fakeWarningTime }
+
+	testutil.CollectAndCompare(t, c, `
+# HELP paperless_warning_last_timestamp_seconds Number of seconds since 1970 of the last warning in a category.
+# TYPE paperless_warning_last_timestamp_seconds gauge
+paperless_warning_last_timestamp_seconds{category="get_remote_version"} 1.5778368e+09
+paperless_warning_last_timestamp_seconds{category="invalid_metric"} 1.5778368e+09
+# HELP paperless_warnings_total Number of warnings generated while scraping metrics.
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 1
+paperless_warnings_total{category="invalid_metric"} 2
+paperless_warnings_total{category="timeout"} 0
+paperless_warnings_total{category="unspecified"} 0
+`)
+
+	want := []map[string]any{
+		{
+			"level":     "INFO",
+			"msg":       "Collecting",
+			"collector": "custom",
+		},
+		{
+			"level":     "WARN",
+			"msg":       "Metrics collection warning",
+			"collector": "custom",
+			"category":  "invalid_metric",
+			"endpoint":  "",
+			"err":       "bad label",
+		},
+		{
+			"level":     "WARN",
+			"msg":       "Metrics collection warning",
+			"collector": "custom",
+			"category":  "get_remote_version",
+			"endpoint":  "/api/remote_version/",
+			"err":       `Get "http://localhost/api/remote_version/?x=1": refused`,
+		},
+	}
+
+	if diff := cmp.Diff(want, decodeLogLines(t, &buf)); diff != "" {
+		t.Errorf("Log diff (-want +got):\n%s", diff)
+	}
+
+	// Repeats are suppressed.
+	testutil.CollectAndCompare(t, c, "", "paperless_none")
+
+	if diff := cmp.Diff(want[:1], decodeLogLines(t, &buf)); diff != "" {
+		t.Errorf("Log diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestRepeatLimiter(t *testing.T) {
+	now := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	l := newRepeatLimiter(time.Minute)
+	l.now = func() time.Time { return now }
+
+	type result struct {
+		Allowed    bool
+		Suppressed int
+	}
+
+	check := func(key string, want result) {
+		t.Helper()
+
+		allowed, suppressed := l.allow(key)
+
+		if diff := cmp.Diff(want, result{allowed, suppressed}); diff != "" {
+			t.Errorf("allow(%q) diff (-want +got):\n%s", key, diff)
+		}
+	}
+
+	check("a", result{Allowed: true})
+	check("a", result{})
+	check("b", result{Allowed: true})
+
+	now = now.Add(30 * time.Second)
+
+	check("a", result{})
+
+	now = now.Add(30 * time.Second)
+
+	check("a", result{Allowed: true, Suppressed: 2})
+	check("a", result{})
+	check("b", result{Allowed: true})
+}
+
+func TestLoggerDefault(t *testing.T) {
+	if got := Logger(context.Background()); got != slog.Default() {
+		t.Errorf("Logger() = %v, want default logger", got)
+	}
+}
@@ -0,0 +1,86 @@
+package collector
+
+import (
+	"sync"
+	"time"
+)
+
+// MemberStatus describes the outcome of the most recent collections of a
+// member.
+type MemberStatus struct {
+	// Collector ID.
+	ID string
+
+	// Start and duration of the most recent collection. Zero if the member
+	// hasn't been collected yet.
+	LastRun      time.Time
+	LastDuration time.Duration
+
+	// End of the most recent successful collection, if any.
+	LastSuccess time.Time
+
+	// Most recent failure, if any. Retained after later successes.
+	LastError     string
+	LastErrorTime time.Time
+}
+
+// memberResults keeps the status of members by collector ID.
+type memberResults struct {
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*MemberStatus
+}
+
+func newMemberResults() *memberResults {
+	return &memberResults{
+		now:     time.Now,
+		entries: map[string]*MemberStatus{},
+	}
+}
+
+// start returns a function recording the outcome of a collection of the
+// given member beginning now. Nothing is recorded on a nil receiver.
+func (r *memberResults) start(id string) func(error) {
+	if r == nil {
+		return func(error) {}
+	}
+
+	start := r.now()
+
+	return func(err error) {
+		end := r.now()
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		s := r.entries[id]
+
+		if s == nil {
+			s = &MemberStatus{ID: id}
+			r.entries[id] = s
+		}
+
+		s.LastRun = start
+		s.LastDuration = end.Sub(start)
+
+		if err == nil {
+			s.LastSuccess = end
+		} else {
+			s.LastError = err.Error()
+			s.LastErrorTime = end
+		}
+	}
+}
+
+// get returns the status of the given member.
+func (r *memberResults) get(id string) MemberStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if s := r.entries[id]; s != nil {
+		return *s
+	}
+
+	return MemberStatus{ID: id}
+}
@@ -0,0 +1,139 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hansmi/prometheus-paperless-exporter/internal/testutil"
+)
+
+func TestCollectorStatus(t *testing.T) {
+	var mu sync.Mutex
+
+	now := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	groups := &fakeGroupClient{count: 1}
+
+	c := newMultiCollector(newGroupCollector(groups), newUserCollector(&fakeUserClient{}))
+	c.ids = []string{"group", "user"}
+	c.logger = slog.New(slog.DiscardHandler)
+	c.results.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+
+		// Every call advances the clock.
+		now = now.Add(time.Second)
+		return now
+	}
+
+	if diff := cmp.Diff([]MemberStatus{{ID: "group"}, {ID: "user"}}, c.Status()); diff != "" {
+		t.Errorf("Status() diff (-want +got):\n%s", diff)
+	}
+
+	groups.err = errors.New("test error")
+
+	c.Collect(testutil.DiscardMetrics(t))
+
+	first := c.Status()
+
+	if diff := cmp.Diff("test error", first[0].LastError); diff != "" {
+		t.Errorf("Last error diff (-want +got):\n%s", diff)
+	}
+
+	if !first[0].LastSuccess.IsZero() {
+		t.Errorf("Last success is %v, want zero", first[0].LastSuccess)
+	}
+
+	if first[0].LastDuration <= 0 {
+		t.Errorf("Last duration is %v, want positive", first[0].LastDuration)
+	}
+
+	groups.err = nil
+
+	c.Collect(testutil.DiscardMetrics(t))
+
+	second := c.Status()
+
+	for _, s := range second {
+		if s.LastSuccess.IsZero() || s.LastRun.IsZero() {
+			t.Errorf("Status %+v lacks successful run", s)
+		}
+	}
+
+	// Previous failures are retained.
+	if diff := cmp.Diff(first[0].LastError, second[0].LastError); diff != "" {
+		t.Errorf("Last error diff (-want +got):\n%s", diff)
+	}
+
+	// Filtered collectors share the status.
+	filtered, err := c.Filter([]string{"user"}, nil)
+	if err != nil {
+		t.Fatalf("Filter() failed: %v", err)
+	}
+
+	if diff := cmp.Diff(second[1:], filtered.Status()); diff != "" {
+		t.Errorf("Status() of filtered collector diff (-want +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff([]string{"user"}, filtered.EnabledIDs()); diff != "" {
+		t.Errorf("EnabledIDs() diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestCachedMemberStatus(t *testing.T) {
+	errTest := errors.New("test error")
+
+	now := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	cl := fakeTagClient{err: errTest}
+
+	m := newCachedMember("tag", newTagCollector(&cl), time.Minute)
+	m.background = true
+	m.now = clock
+
+	c := newMultiCollectorForTest(t, m)
+	c.ids = []string{"tag"}
+	c.results.now = clock
+
+	report := func(*warning) {}
+
+	// Failed refreshes are recorded although serving the snapshot succeeds.
+	m.refresh(context.Background(), report)
+
+	now = now.Add(time.Minute)
+
+	c.Collect(testutil.DiscardMetrics(t))
+
+	if diff := cmp.Diff([]MemberStatus{{
+		ID:            "tag",
+		LastRun:       now.Add(-time.Minute),
+		LastError:     "test error",
+		LastErrorTime: now.Add(-time.Minute),
+	}}, c.Status()); diff != "" {
+		t.Errorf("Status() diff (-want +got):\n%s", diff)
+	}
+
+	cl.err = nil
+
+	m.refresh(context.Background(), report)
+
+	now = now.Add(time.Minute)
+
+	c.Collect(testutil.DiscardMetrics(t))
+
+	if diff := cmp.Diff([]MemberStatus{{
+		ID:            "tag",
+		LastRun:       now.Add(-time.Minute),
+		LastSuccess:   now.Add(-time.Minute),
+		LastError:     "test error",
+		LastErrorTime: now.Add(-2 * time.Minute),
+	}}, c.Status()); diff != "" {
+		t.Errorf("Status() diff (-want +got):\n%s", diff)
+	}
+}
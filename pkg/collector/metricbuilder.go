@@ -0,0 +1,86 @@
+package collector
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// sanitizeLabelValue replaces invalid UTF-8 sequences. The second return
+// value reports whether the value was modified.
+func sanitizeLabelValue(value string) (string, bool) {
+	if utf8.ValidString(value) {
+		return value, false
+	}
+
+	return strings.ToValidUTF8(value, string(utf8.RuneError)), true
+}
+
+type metricKey struct {
+	desc   *prometheus.Desc
+	labels string
+}
+
+// metricBuilder creates constant metrics during a single collection run
+// without ever panicking. Invalid label values are sanitized, duplicate series
+// and otherwise invalid metrics are dropped. All problems are reported as
+// warnings. Warning messages never contain label values as those may be
+// subject to redaction.
+type metricBuilder struct {
+	ch chan<- prometheus.Metric
+
+	mu   sync.Mutex
+	seen map[metricKey]struct{}
+}
+
+func newMetricBuilder(ch chan<- prometheus.Metric) *metricBuilder {
+	return &metricBuilder{
+		ch:   ch,
+		seen: map[metricKey]struct{}{},
+	}
+}
+
+func (b *metricBuilder) warn(err error) {
+	b.ch <- newWarning(warningCategoryInvalidMetric, err)
+}
+
+// gauge emits a gauge metric.
+func (b *metricBuilder) gauge(desc *prometheus.Desc, value float64, labelValues ...string) {
+	b.emit(desc, prometheus.GaugeValue, value, labelValues...)
+}
+
+func (b *metricBuilder) emit(desc *prometheus.Desc, valueType prometheus.ValueType, value float64, labelValues ...string) {
+	labelValues = append([]string(nil), labelValues...)
+
+	for idx, v := range labelValues {
+		var modified bool
+
+		if labelValues[idx], modified = sanitizeLabelValue(v); modified {
+			b.warn(fmt.Errorf("%s: value of label %d is not valid UTF-8", desc, idx+1))
+		}
+	}
+
+	key := metricKey{desc, strings.Join(labelValues, "\xff")}
+
+	b.mu.Lock()
+	_, duplicate := b.seen[key]
+	b.seen[key] = struct{}{}
+	b.mu.Unlock()
+
+	if duplicate {
+		b.warn(fmt.Errorf("%s: duplicate series", desc))
+		return
+	}
+
+	m, err := prometheus.NewConstMetric(desc, valueType, value, labelValues...)
+	if err != nil {
+		// The error may include the label values.
+		b.warn(fmt.Errorf("%s: invalid metric with %d label values", desc, len(labelValues)))
+		return
+	}
+
+	b.ch <- m
+}
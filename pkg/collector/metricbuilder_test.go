@@ -0,0 +1,96 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/hansmi/paperhooks/pkg/client"
+	"github.com/hansmi/prometheus-paperless-exporter/internal/testutil"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestSanitizeLabelValue(t *testing.T) {
+	for _, tc := range []struct {
+		value        string
+		want         string
+		wantModified bool
+	}{
+		{value: "", want: ""},
+		{value: "text", want: "text"},
+		{value: "café", want: "café"},
+		{value: "a\xffb", want: "a�b", wantModified: true},
+		{value: "\xc3", want: "�", wantModified: true},
+	} {
+		t.Run(tc.value, func(t *testing.T) {
+			got, modified := sanitizeLabelValue(tc.value)
+
+			if got != tc.want || modified != tc.wantModified {
+				t.Errorf("sanitizeLabelValue(%q) = (%q, %v), want (%q, %v)", tc.value, got, modified, tc.want, tc.wantModified)
+			}
+		})
+	}
+}
+
+func TestMetricBuilder(t *testing.T) {
+	desc := prometheus.NewDesc("test_metric", "Help.", []string{"name"}, nil)
+
+	ch := make(chan prometheus.Metric, 10)
+
+	b := newMetricBuilder(ch)
+	b.gauge(desc, 1, "first")
+	b.gauge(desc, 2, "first")
+	b.gauge(desc, 3, "second\xff")
+	b.gauge(desc, 4)
+	b.gauge(desc, 5, "second�")
+
+	close(ch)
+
+	var warnings, metrics int
+
+	for m := range ch {
+		if _, ok := m.(*warning); ok {
+			warnings++
+		} else {
+			metrics++
+		}
+	}
+
+	// The sanitized label value of the third series is identical to the one
+	// of the last.
+	if metrics != 2 || warnings != 4 {
+		t.Errorf("Got %d metrics and %d warnings, want 2 and 4", metrics, warnings)
+	}
+}
+
+func TestMetricBuilderCollect(t *testing.T) {
+	cl := fakeTagClient{
+		items: []client.Tag{
+			{ID: 10, Name: "invalid\xff"},
+			{ID: 10, Name: "duplicate", DocumentCount: 4},
+		},
+	}
+
+	c := newMultiCollectorForTest(t, newTagCollector(&cl))
+
+	testutil.CollectAndCompare(t, c, `
+# HELP paperless_tag_document_count Number of documents associated with a tag.
+# TYPE paperless_tag_document_count gauge
+paperless_tag_document_count{id="10"} 0
+# HELP paperless_tag_inbox Whether the tag is marked as an inbox tag.
+# TYPE paperless_tag_inbox gauge
+paperless_tag_inbox{id="10"} 0
+# HELP paperless_tag_info Static information about a tag.
+# TYPE paperless_tag_info gauge
+paperless_tag_info{id="10",name="duplicate",slug=""} 1
+paperless_tag_info{id="10",name="invalid�",slug=""} 1
+# HELP paperless_warning_last_timestamp_seconds Number of seconds since 1970 of the last warning in a category.
+# TYPE paperless_warning_last_timestamp_seconds gauge
+paperless_warning_last_timestamp_seconds{category="invalid_metric"} 1.5778368e+09
+# HELP paperless_warnings_total Number of warnings generated while scraping metrics.
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 3
+paperless_warnings_total{category="timeout"} 0
+paperless_warnings_total{category="unspecified"} 0
+`)
+}
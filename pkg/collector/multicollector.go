@@ -0,0 +1,445 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hansmi/prometheus-paperless-exporter/pkg/relabel"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
+)
+
+// Member is an individual collector combined with others into a [Collector].
+type Member interface {
+	// Describe sends the descriptors of all metrics reported by the member.
+	Describe(chan<- *prometheus.Desc)
+
+	// Collect sends the member's metrics. Errors fail the whole scrape.
+	Collect(context.Context, chan<- prometheus.Metric) error
+}
+
+// Collector reports the metrics of multiple members. The members are
+// collected concurrently.
+type Collector struct {
+	// Impose a timeout on collection if non-zero.
+	timeout time.Duration
+
+	// Safety margin subtracted from the scrape timeout announced by
+	// Prometheus.
+	timeoutOffset time.Duration
+
+	logger *slog.Logger
+
+	// Suppresses repeated log messages. Shared with filtered collectors.
+	logLimiter *repeatLimiter
+
+	// Cumulative warning counts and recent warnings. Shared with filtered
+	// collectors.
+	warnings *warningRecorder
+
+	// Outcome of the most recent collection of each member. Shared with
+	// filtered collectors.
+	results *memberResults
+
+	members []Member
+
+	// Collector IDs of the members in the same order, if known.
+	ids []string
+
+	// Concurrent collections of the same members are collapsed into a single
+	// run. Shared with filtered collectors.
+	flight *singleflight.Group
+
+	// Counter of scrapes served from a run started by another scrape, if
+	// non-nil.
+	collapsed prometheus.Counter
+
+	// Invoked whenever a caller has joined a collection run, if non-nil.
+	// Used by tests.
+	flightJoined func()
+
+	// Relabeling rules applied to gathered metrics.
+	relabelConfigs []*relabel.Config
+
+	tracer trace.Tracer
+}
+
+var _ prometheus.Collector = (*Collector)(nil)
+
+func newMultiCollector(m ...Member) *Collector {
+	c := &Collector{
+		logger:     slog.Default(),
+		logLimiter: newRepeatLimiter(defaultLogRepeatInterval),
+		warnings:   newWarningRecorder(defaultRecentWarnings),
+		results:    newMemberResults(),
+		members:    m,
+		flight:     &singleflight.Group{},
+		tracer:     otel.GetTracerProvider().Tracer(tracerName),
+	}
+
+	c.shareResults()
+
+	return c
+}
+
+// shareResults lets cached members record the outcome of their refreshes.
+func (c *Collector) shareResults() {
+	for _, i := range c.members {
+		if cm, ok := i.(*cachedMember); ok {
+			cm.results = c.results
+		}
+	}
+}
+
+// inheritHistory continues the warning counts, recent warnings and member
+// status of another collector.
+func (c *Collector) inheritHistory(prev *Collector) {
+	c.warnings = prev.warnings
+	c.results = prev.results
+
+	c.shareResults()
+}
+
+// Start launches background refresh loops for members supporting them. The
+// loops terminate when the context is cancelled.
+func (c *Collector) Start(ctx context.Context) {
+	for idx, i := range c.members {
+		if r, ok := i.(interface {
+			run(context.Context, func(*warning))
+		}); ok {
+			name := c.memberName(idx)
+
+			go r.run(ctx, func(w *warning) {
+				c.reportWarning(ctx, w.withCollector(name))
+			})
+		}
+	}
+}
+
+// Filter returns a collector restricted to a subset of the members. Members
+// are selected by ID via include, or all of them if include is empty, and
+// subsequently removed via exclude. All IDs must refer to enabled members.
+func (c *Collector) Filter(include, exclude []string) (*Collector, error) {
+	if len(include) == 0 && len(exclude) == 0 {
+		return c, nil
+	}
+
+	for _, ids := range [][]string{include, exclude} {
+		if err := CheckIDs(ids); err != nil {
+			return nil, err
+		}
+
+		for _, id := range ids {
+			if !slices.Contains(c.ids, id) {
+				return nil, fmt.Errorf("collector not enabled: %s", id)
+			}
+		}
+	}
+
+	result := *c
+	result.ids = nil
+	result.members = nil
+
+	for idx, id := range c.ids {
+		if (len(include) == 0 || slices.Contains(include, id)) && !slices.Contains(exclude, id) {
+			result.ids = append(result.ids, id)
+			result.members = append(result.members, c.members[idx])
+		}
+	}
+
+	return &result, nil
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.warnings.describe(ch)
+
+	for _, i := range c.members {
+		i.Describe(ch)
+	}
+}
+
+func (c *Collector) collectWithWarnings(ctx context.Context, ch chan<- prometheus.Metric) error {
+	var wg sync.WaitGroup
+
+	collected := make(chan prometheus.Metric)
+
+	defer func() {
+		close(collected)
+
+		wg.Wait()
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for m := range collected {
+			if warning, ok := m.(*warning); ok && warning != nil {
+				c.reportWarning(ctx, warning)
+				continue
+			}
+
+			ch <- m
+		}
+
+		c.warnings.collect(ch)
+	}()
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(runtime.GOMAXPROCS(0))
+
+	for idx, i := range c.members {
+		collect := i.Collect
+		name := c.memberName(idx)
+
+		g.Go(func() error {
+			ctx, span := c.tracer.Start(ctx, "collect "+name,
+				trace.WithAttributes(attribute.String("paperless.collector", name)))
+
+			done := func(error) {}
+
+			if _, cached := c.members[idx].(*cachedMember); !cached {
+				done = c.results.start(name)
+			}
+
+			err := collectMember(withLogger(ctx, c.logger.With("collector", name)), name, collect, collected)
+
+			done(err)
+			endSpan(span, err)
+
+			if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				// Report partial results when the scrape deadline expires.
+				collected <- newWarning(warningCategoryTimeout, fmt.Errorf("collector %s: %w", name, err)).
+					withCollector(name)
+
+				return nil
+			}
+
+			if err != nil && !(errors.Is(err, context.Canceled) && ctx.Err() != nil) {
+				// Failures caused by another member failing are not logged.
+				logLimited(ctx, c.logger, c.logLimiter, slog.LevelError, "Metrics collection failed",
+					slog.String("collector", name),
+					slog.String("endpoint", endpointFromError(err)),
+					slog.Any("err", err))
+			}
+
+			return err
+		})
+	}
+
+	return g.Wait()
+}
+
+// memberName returns the collector ID of the member at the given index.
+func (c *Collector) memberName(idx int) string {
+	if idx < len(c.ids) {
+		return c.ids[idx]
+	}
+
+	return fmt.Sprintf("#%d", idx)
+}
+
+// collectMember collects the metrics of a single member and attributes its
+// warnings to the given collector ID.
+func collectMember(ctx context.Context, name string, collect func(context.Context, chan<- prometheus.Metric) error, ch chan<- prometheus.Metric) error {
+	collected := make(chan prometheus.Metric)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for m := range collected {
+			if w, ok := m.(*warning); ok && w != nil {
+				m = w.withCollector(name)
+			}
+
+			ch <- m
+		}
+	}()
+
+	err := collect(ctx, collected)
+
+	close(collected)
+	<-done
+
+	return err
+}
+
+// reportWarning counts, retains and logs a warning.
+func (c *Collector) reportWarning(ctx context.Context, w *warning) {
+	c.warnings.record(w)
+	c.logWarning(ctx, w)
+}
+
+// logWarning logs a warning unless it has been logged recently.
+func (c *Collector) logWarning(ctx context.Context, w *warning) {
+	logLimited(ctx, c.logger, c.logLimiter, slog.LevelWarn, "Metrics collection warning",
+		slog.String("collector", w.collector),
+		slog.String("category", w.category.String()),
+		slog.String("endpoint", w.resolvedEndpoint()),
+		slog.Any("err", w.err))
+}
+
+// RecentWarnings returns the most recent warnings reported during
+// collections, most recent first.
+func (c *Collector) RecentWarnings() []WarningRecord {
+	return c.warnings.recentWarnings()
+}
+
+// EnabledIDs returns the IDs of all members in collection order.
+func (c *Collector) EnabledIDs() []string {
+	return slices.Clone(c.ids)
+}
+
+// Status returns the outcome of the most recent collections of all members.
+func (c *Collector) Status() []MemberStatus {
+	result := make([]MemberStatus, 0, len(c.members))
+
+	for idx := range c.members {
+		result = append(result, c.results.get(c.memberName(idx)))
+	}
+
+	return result
+}
+
+// boundCollector collects metrics using a scrape-specific context.
+type boundCollector struct {
+	*Collector
+
+	ctx context.Context
+}
+
+var _ prometheus.Collector = (*boundCollector)(nil)
+
+func (c *boundCollector) Collect(ch chan<- prometheus.Metric) {
+	c.collectContext(c.ctx, ch)
+}
+
+// TimeoutOffset returns the safety margin to subtract from the scrape timeout
+// announced by Prometheus.
+func (c *Collector) TimeoutOffset() time.Duration {
+	return c.timeoutOffset
+}
+
+// WithContext returns a collector using the given context for collection.
+func (c *Collector) WithContext(ctx context.Context) prometheus.Collector {
+	return &boundCollector{c, ctx}
+}
+
+// Gatherer returns a gatherer for the metrics collected using the given
+// context with relabeling rules applied.
+func (c *Collector) Gatherer(ctx context.Context) (prometheus.Gatherer, error) {
+	reg := prometheus.NewPedanticRegistry()
+
+	if err := reg.Register(c.WithContext(ctx)); err != nil {
+		return nil, err
+	}
+
+	return newRelabelingGatherer(reg, c.relabelConfigs), nil
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.collectContext(context.Background(), ch)
+}
+
+// collectShared collects metrics from all members. Callers arriving while a
+// collection of the same members is in progress wait for and receive its
+// results instead of starting another one. The collection uses the deadline of
+// the caller starting it, but isn't cancelled when that caller goes away.
+// Every caller stops waiting once its own context is done.
+func (c *Collector) collectShared(ctx context.Context) ([]prometheus.Metric, error) {
+	var leader atomic.Bool
+
+	ch := c.flight.DoChan(strings.Join(c.ids, ","), func() (any, error) {
+		leader.Store(true)
+
+		runCtx := context.WithoutCancel(ctx)
+
+		if deadline, ok := ctx.Deadline(); ok {
+			var cancel context.CancelFunc
+			runCtx, cancel = context.WithDeadline(runCtx, deadline)
+			defer cancel()
+		}
+
+		var metrics []prometheus.Metric
+
+		collected := make(chan prometheus.Metric)
+		done := make(chan struct{})
+
+		go func() {
+			defer close(done)
+
+			for m := range collected {
+				metrics = append(metrics, m)
+			}
+		}()
+
+		err := c.collectWithWarnings(runCtx, collected)
+
+		close(collected)
+		<-done
+
+		return metrics, err
+	})
+
+	if c.flightJoined != nil {
+		c.flightJoined()
+	}
+
+	var r singleflight.Result
+
+	select {
+	case r = <-ch:
+	case <-ctx.Done():
+		if !(leader.Load() && errors.Is(ctx.Err(), context.DeadlineExceeded)) {
+			return nil, ctx.Err()
+		}
+
+		// The collection ends at the same deadline and reports partial
+		// results.
+		r = <-ch
+	}
+
+	if !leader.Load() && c.collapsed != nil {
+		c.collapsed.Inc()
+	}
+
+	return r.Val.([]prometheus.Metric), r.Err
+}
+
+func (c *Collector) collectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	if c.timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	ctx, span := c.tracer.Start(ctx, "scrape",
+		trace.WithAttributes(attribute.StringSlice("paperless.collectors", c.ids)))
+
+	metrics, err := c.collectShared(ctx)
+
+	endSpan(span, err)
+
+	for _, m := range metrics {
+		ch <- m
+	}
+
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(
+			prometheus.NewDesc("paperless_error", "Metrics collection failed", nil, nil),
+			err)
+	}
+}
@@ -0,0 +1,208 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hansmi/prometheus-paperless-exporter/internal/testutil"
+	"github.com/prometheus/client_golang/prometheus"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// Time of all warnings recorded by test collectors.
+var fakeWarningTime = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+func newMultiCollectorForTest(t *testing.T, m Member) *Collector {
+	t.Helper()
+
+	c := newMultiCollector(m)
+	c.logger = slog.New(slog.DiscardHandler)
+	c.warnings.now = func() time.Time { return fakeWarningTime }
+
+	return c
+}
+
+func TestMultiCollectorPartialTimeout(t *testing.T) {
+	c := newMultiCollector(
+		newGroupCollector(&fakeGroupClient{count: 7}),
+		&blockingMember{
+			Member: newUserCollector(&fakeUserClient{}),
+			block:  true,
+		},
+	)
+	c.logger = slog.New(slog.DiscardHandler)
+	c.warnings.now = func() time.Time { return fakeWarningTime }
+	c.ids = []string{"group", "user"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	t.Cleanup(cancel)
+
+	testutil.CollectAndCompare(t, c.WithContext(ctx), `
+# HELP paperless_groups Number of user groups.
+# TYPE paperless_groups gauge
+paperless_groups 7
+# HELP paperless_warning_last_timestamp_seconds Number of seconds since 1970 of the last warning in a category.
+# TYPE paperless_warning_last_timestamp_seconds gauge
+paperless_warning_last_timestamp_seconds{category="timeout"} 1.5778368e+09
+# HELP paperless_warnings_total Number of warnings generated while scraping metrics.
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 1
+paperless_warnings_total{category="unspecified"} 0
+`)
+}
+
+type gatedMember struct {
+	Member
+
+	calls   atomic.Int32
+	started chan struct{}
+	release chan struct{}
+}
+
+func (m *gatedMember) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	if m.calls.Add(1) == 1 {
+		close(m.started)
+	}
+
+	<-m.release
+
+	return m.Member.Collect(ctx, ch)
+}
+
+func TestMultiCollectorCollapse(t *testing.T) {
+	const scrapes = 4
+
+	m := &gatedMember{
+		Member:  newGroupCollector(&fakeGroupClient{count: 3}),
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+
+	joined := make(chan struct{}, scrapes)
+
+	c := newMultiCollectorForTest(t, m)
+	c.collapsed = prometheus.NewCounter(prometheus.CounterOpts{Name: "test"})
+	c.flightJoined = func() { joined <- struct{}{} }
+
+	var wg sync.WaitGroup
+
+	for i := range scrapes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			testutil.CollectAndCompare(t, c, `
+# HELP paperless_groups Number of user groups.
+# TYPE paperless_groups gauge
+paperless_groups 3
+`, "paperless_groups")
+		}()
+
+		if i == 0 {
+			<-m.started
+		}
+	}
+
+	// Wait for all scrapes to join the run in progress.
+	for range scrapes {
+		<-joined
+	}
+
+	close(m.release)
+
+	wg.Wait()
+
+	if got := m.calls.Load(); got != 1 {
+		t.Errorf("Member collected %d times, want 1", got)
+	}
+
+	if got := promtestutil.ToFloat64(c.collapsed); got != scrapes-1 {
+		t.Errorf("Collapsed scrapes %v, want %d", got, scrapes-1)
+	}
+
+	// Later scrapes start a new run.
+	c.flightJoined = nil
+
+	testutil.CollectAndCompare(t, c, "", "paperless_unknown")
+
+	if got := m.calls.Load(); got != 2 {
+		t.Errorf("Member collected %d times, want 2", got)
+	}
+}
+
+func TestMultiCollectorCollapseOwnContext(t *testing.T) {
+	m := &gatedMember{
+		Member:  newGroupCollector(&fakeGroupClient{count: 3}),
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+
+	joined := make(chan struct{}, 3)
+
+	c := newMultiCollectorForTest(t, m)
+	c.flightJoined = func() { joined <- struct{}{} }
+
+	leaderCtx, leaderCancel := context.WithCancel(context.Background())
+	t.Cleanup(leaderCancel)
+
+	type result struct {
+		metrics []prometheus.Metric
+		err     error
+	}
+
+	leaderResult := make(chan result, 1)
+
+	go func() {
+		metrics, err := c.collectShared(leaderCtx)
+		leaderResult <- result{metrics, err}
+	}()
+
+	<-m.started
+	<-joined
+
+	followerResult := make(chan result, 1)
+
+	go func() {
+		metrics, err := c.collectShared(context.Background())
+		followerResult <- result{metrics, err}
+	}()
+
+	<-joined
+
+	// A follower with a short deadline stops waiting on its own.
+	shortCtx, shortCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	t.Cleanup(shortCancel)
+
+	if _, err := c.collectShared(shortCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("collectShared() returned %v, want deadline error", err)
+	}
+
+	<-joined
+
+	// The leader going away doesn't affect the other callers.
+	leaderCancel()
+
+	if r := <-leaderResult; !errors.Is(r.err, context.Canceled) {
+		t.Errorf("collectShared() of leader returned %v, want cancellation", r.err)
+	}
+
+	close(m.release)
+
+	if r := <-followerResult; r.err != nil {
+		t.Errorf("collectShared() of follower failed: %v", r.err)
+	} else if len(r.metrics) == 0 {
+		t.Errorf("collectShared() of follower returned no metrics")
+	}
+
+	if got := m.calls.Load(); got != 1 {
+		t.Errorf("Member collected %d times, want 1", got)
+	}
+}
@@ -0,0 +1,165 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/hansmi/paperhooks/pkg/client"
+	"golang.org/x/sync/errgroup"
+)
+
+// Maximum number of pages fetched ahead of the page currently being
+// processed. The number of concurrent API requests is further restricted by
+// the global API limiter.
+const maxParallelPages = 4
+
+// listPageFunc retrieves a single page of a listing. The first page is
+// requested with nil page options.
+type listPageFunc[T any] func(context.Context, *client.PageOptions) ([]T, *client.Response, error)
+
+type fetchedPage[T any] struct {
+	ready chan struct{}
+	items []T
+	resp  *client.Response
+	err   error
+
+	// The page is beyond the end of the listing.
+	outOfRange bool
+}
+
+// isPageOutOfRange reports whether the error is Paperless' response to
+// a request for a page beyond the end of a listing, e.g. because objects were
+// deleted since the item count was determined.
+func isPageOutOfRange(err error) bool {
+	var reqErr *client.RequestError
+
+	return errors.As(err, &reqErr) && reqErr.StatusCode == http.StatusNotFound
+}
+
+// fetchAllPages invokes the handler for all items of a listing. The number of
+// pages is determined from the item count reported with the first page and
+// the remaining pages are fetched concurrently. Items are passed to the
+// handler in listing order. Pages are fetched sequentially if the item count
+// is unknown. Pages added while fetching are followed sequentially and an
+// out-of-range page ends the listing.
+func fetchAllPages[T any](ctx context.Context, fetch listPageFunc[T], handler func(context.Context, T) error) error {
+	fetch = tracedPageFunc(fetch)
+
+	items, resp, err := fetch(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, i := range items {
+		if err := handler(ctx, i); err != nil {
+			return err
+		}
+	}
+
+	if resp.NextPage == nil {
+		return nil
+	}
+
+	pageSize := len(items)
+
+	if resp.ItemCount == client.ItemCountUnknown || pageSize == 0 {
+		return fetchPagesSequential(ctx, fetch, resp.NextPage, handler)
+	}
+
+	numPages := int((resp.ItemCount + int64(pageSize) - 1) / int64(pageSize))
+
+	if numPages < 2 {
+		return fetchPagesSequential(ctx, fetch, resp.NextPage, handler)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pages := make([]*fetchedPage[T], numPages-1)
+
+	for idx := range pages {
+		pages[idx] = &fetchedPage[T]{ready: make(chan struct{})}
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(maxParallelPages)
+
+	scheduled := make(chan struct{})
+
+	go func() {
+		defer close(scheduled)
+
+		for idx, p := range pages {
+			opts := &client.PageOptions{
+				Number: idx + 2,
+				Size:   pageSize,
+			}
+
+			g.Go(func() error {
+				defer close(p.ready)
+
+				p.items, p.resp, p.err = fetch(gctx, opts)
+
+				if isPageOutOfRange(p.err) {
+					p.outOfRange = true
+					p.err = nil
+				}
+
+				return p.err
+			})
+		}
+	}()
+
+	defer func() {
+		cancel()
+		<-scheduled
+		g.Wait()
+	}()
+
+	for _, p := range pages {
+		<-p.ready
+
+		if p.err != nil {
+			// Report the first failure instead of cancellations caused by it.
+			<-scheduled
+
+			return g.Wait()
+		}
+
+		if p.outOfRange {
+			return nil
+		}
+
+		for _, i := range p.items {
+			if err := handler(ctx, i); err != nil {
+				return err
+			}
+		}
+	}
+
+	return fetchPagesSequential(ctx, fetch, pages[len(pages)-1].resp.NextPage, handler)
+}
+
+// fetchPagesSequential follows the next-page links starting at the given page.
+// An out-of-range page ends the listing.
+func fetchPagesSequential[T any](ctx context.Context, fetch listPageFunc[T], page *client.PageOptions, handler func(context.Context, T) error) error {
+	for page != nil {
+		items, resp, err := fetch(ctx, page)
+		if isPageOutOfRange(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		for _, i := range items {
+			if err := handler(ctx, i); err != nil {
+				return err
+			}
+		}
+
+		page = resp.NextPage
+	}
+
+	return nil
+}
@@ -0,0 +1,228 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hansmi/paperhooks/pkg/client"
+)
+
+const fakePageSize = 2
+
+// fakeListPage returns a single page of a listing.
+func fakeListPage[T any](items []T, err error, page *client.PageOptions) ([]T, *client.Response, error) {
+	if err != nil {
+		return nil, nil, err
+	}
+
+	number := 1
+	size := fakePageSize
+
+	if page != nil {
+		number = max(1, page.Number)
+
+		if page.Size > 0 {
+			size = page.Size
+		}
+	}
+
+	start := min(len(items), (number-1)*size)
+	end := min(len(items), start+size)
+
+	resp := &client.Response{
+		ItemCount: int64(len(items)),
+	}
+
+	if end < len(items) {
+		resp.NextPage = &client.PageOptions{Number: number + 1}
+	}
+
+	return items[start:end], resp, nil
+}
+
+func TestFetchAllPages(t *testing.T) {
+	errTest := errors.New("test error")
+
+	var items []int
+
+	for i := range 25 {
+		items = append(items, i)
+	}
+
+	for _, tc := range []struct {
+		name         string
+		items        []int
+		unknownCount bool
+
+		// Items returned for pages after the first one, if non-nil.
+		laterItems []int
+
+		failPage    int
+		failHandler int
+		want        []int
+
+		// Only a prefix of the wanted items is received.
+		wantPrefix bool
+
+		wantErr error
+	}{
+		{name: "empty"},
+		{
+			name:  "single page",
+			items: items[:fakePageSize],
+			want:  items[:fakePageSize],
+		},
+		{
+			name:  "many pages",
+			items: items,
+			want:  items,
+		},
+		{
+			name:         "unknown item count",
+			items:        items,
+			unknownCount: true,
+			want:         items,
+		},
+		{
+			name:     "first page fails",
+			items:    items,
+			failPage: 1,
+			wantErr:  errTest,
+		},
+		{
+			name:       "later page fails",
+			items:      items,
+			failPage:   5,
+			want:       items[:4*fakePageSize],
+			wantPrefix: true,
+			wantErr:    errTest,
+		},
+		{
+			name:       "items deleted",
+			items:      items,
+			laterItems: items[:15],
+			want:       items[:15],
+		},
+		{
+			name:       "all later items deleted",
+			items:      items,
+			laterItems: items[:fakePageSize],
+			want:       items[:fakePageSize],
+		},
+		{
+			name:       "items added",
+			items:      items,
+			laterItems: append(slices.Clone(items), 25, 26, 27, 28, 29),
+			want:       append(slices.Clone(items), 25, 26, 27, 28, 29),
+		},
+		{
+			name:         "items deleted with unknown item count",
+			items:        items,
+			laterItems:   items[:15],
+			unknownCount: true,
+			want:         items[:15],
+		},
+		{
+			name:        "handler fails",
+			items:       items,
+			failHandler: 17,
+			want:        items[:17],
+			wantErr:     errTest,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var active, peak atomic.Int32
+
+			fetch := func(ctx context.Context, page *client.PageOptions) ([]int, *client.Response, error) {
+				n := active.Add(1)
+				defer active.Add(-1)
+
+				for {
+					old := peak.Load()
+
+					if n <= old || peak.CompareAndSwap(old, n) {
+						break
+					}
+				}
+
+				number := 1
+				listing := tc.items
+
+				if page != nil {
+					number = page.Number
+
+					if number == tc.failPage {
+						return nil, nil, errTest
+					}
+
+					if tc.laterItems != nil {
+						listing = tc.laterItems
+					}
+
+					if (number-1)*fakePageSize >= len(listing) {
+						return nil, nil, &client.RequestError{StatusCode: http.StatusNotFound}
+					}
+
+					// Complete pages out of order.
+					select {
+					case <-time.After(time.Duration(rand.IntN(1000)) * time.Microsecond):
+					case <-ctx.Done():
+						return nil, nil, ctx.Err()
+					}
+				} else if number == tc.failPage {
+					return nil, nil, errTest
+				}
+
+				result, resp, err := fakeListPage(listing, nil, page)
+
+				if resp != nil && tc.unknownCount {
+					resp.ItemCount = client.ItemCountUnknown
+				}
+
+				return result, resp, err
+			}
+
+			var got []int
+
+			err := fetchAllPages(context.Background(), fetch, func(_ context.Context, i int) error {
+				if len(got) == tc.failHandler && tc.failHandler > 0 {
+					return errTest
+				}
+
+				got = append(got, i)
+
+				return nil
+			})
+
+			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("fetchAllPages() error diff (-want +got):\n%s", diff)
+			}
+
+			want := tc.want
+
+			if tc.wantPrefix {
+				want = want[:min(len(got), len(want))]
+			}
+
+			if diff := cmp.Diff(want, got, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("Items diff (-want +got):\n%s", diff)
+			}
+
+			if got := peak.Load(); got > maxParallelPages {
+				t.Errorf("Fetched %d pages concurrently, want at most %d", got, maxParallelPages)
+			}
+
+			if !slices.IsSorted(got) {
+				t.Errorf("Items not in listing order: %v", got)
+			}
+		})
+	}
+}
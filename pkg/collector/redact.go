@@ -0,0 +1,236 @@
+package collector
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+const (
+	redactDrop     = "drop"
+	redactHash     = "hash"
+	redactTruncate = "truncate"
+	redactAllow    = "allow"
+)
+
+// Number of hex digits retained from hashed label values.
+const redactHashLength = 16
+
+// RedactionConfig describes how values of a label are redacted.
+type RedactionConfig struct {
+	// One of "drop", "hash", "truncate" or "allow".
+	Action string `yaml:"action"`
+
+	// Secret salt for hashing, given directly or read from a file.
+	Salt     string `yaml:"salt"`
+	SaltFile string `yaml:"salt_file"`
+
+	// Maximum number of characters retained when truncating.
+	Length int `yaml:"length"`
+
+	// Regular expression values must match in full to be retained.
+	Allow string `yaml:"allow"`
+}
+
+// RedactionPolicy is a validated redaction configuration. Policies are built
+// via [RedactionConfig.Build].
+type RedactionPolicy struct {
+	action string
+	salt   []byte
+	length int
+	allow  *regexp.Regexp
+}
+
+// Build validates the configuration.
+func (c RedactionConfig) Build() (*RedactionPolicy, error) {
+	p := &RedactionPolicy{action: c.Action}
+
+	switch c.Action {
+	case redactDrop:
+
+	case redactHash:
+		p.salt = []byte(c.Salt)
+
+		if c.SaltFile != "" {
+			content, err := os.ReadFile(c.SaltFile)
+			if err != nil {
+				return nil, fmt.Errorf("reading salt: %w", err)
+			}
+
+			p.salt = []byte(strings.TrimSpace(string(content)))
+		}
+
+		if len(p.salt) == 0 {
+			return nil, errors.New("hashing requires a salt")
+		}
+
+	case redactTruncate:
+		if c.Length < 1 {
+			return nil, fmt.Errorf("truncation length must be positive, got %d", c.Length)
+		}
+
+		p.length = c.Length
+
+	case redactAllow:
+		re, err := regexp.Compile("^(?:" + c.Allow + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("allow pattern: %w", err)
+		}
+
+		p.allow = re
+
+	default:
+		return nil, fmt.Errorf("unknown redaction action %q", c.Action)
+	}
+
+	return p, nil
+}
+
+// apply returns the redacted form of a label value. Empty values are retained.
+func (p *RedactionPolicy) apply(value string) string {
+	if value == "" {
+		return value
+	}
+
+	switch p.action {
+	case redactHash:
+		mac := hmac.New(sha256.New, p.salt)
+		mac.Write([]byte(value))
+
+		return hex.EncodeToString(mac.Sum(nil))[:redactHashLength]
+
+	case redactTruncate:
+		if runes := []rune(value); len(runes) > p.length {
+			return string(runes[:p.length])
+		}
+
+		return value
+
+	case redactAllow:
+		if p.allow.MatchString(value) {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// redactedMetric reports the wrapped metric with modified label values.
+type redactedMetric struct {
+	prometheus.Metric
+
+	labels []*dto.LabelPair
+}
+
+func (m *redactedMetric) Write(out *dto.Metric) error {
+	if err := m.Metric.Write(out); err != nil {
+		return err
+	}
+
+	out.Label = m.labels
+
+	return nil
+}
+
+// redactingMember applies redaction policies keyed by label name to all
+// metrics of the wrapped member. Series becoming indistinguishable through
+// redaction are reported only once.
+type redactingMember struct {
+	Member
+
+	policies map[string]*RedactionPolicy
+}
+
+func newRedactingMember(m Member, policies map[string]*RedactionPolicy) *redactingMember {
+	return &redactingMember{
+		Member:   m,
+		policies: policies,
+	}
+}
+
+func (m *redactingMember) redact(metric prometheus.Metric) (prometheus.Metric, string, error) {
+	var pb dto.Metric
+
+	if err := metric.Write(&pb); err != nil {
+		return nil, "", err
+	}
+
+	var key strings.Builder
+
+	key.WriteString(metric.Desc().String())
+
+	labels := make([]*dto.LabelPair, 0, len(pb.Label))
+
+	for _, lp := range pb.Label {
+		value := lp.GetValue()
+
+		if p := m.policies[lp.GetName()]; p != nil {
+			value = p.apply(value)
+		}
+
+		labels = append(labels, &dto.LabelPair{
+			Name:  lp.Name,
+			Value: &value,
+		})
+
+		key.WriteByte(0xff)
+		key.WriteString(value)
+	}
+
+	return &redactedMetric{Metric: metric, labels: labels}, key.String(), nil
+}
+
+func (m *redactingMember) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	var wg sync.WaitGroup
+
+	collected := make(chan prometheus.Metric)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		seen := map[string]struct{}{}
+
+		for metric := range collected {
+			if _, ok := metric.(*warning); ok {
+				ch <- metric
+				continue
+			}
+
+			redacted, key, err := m.redact(metric)
+			if err != nil {
+				// The error may include unredacted label values.
+				ch <- newWarning(warningCategoryInvalidMetric,
+					fmt.Errorf("%s: invalid metric", metric.Desc()))
+				continue
+			}
+
+			if _, ok := seen[key]; ok {
+				ch <- newWarning(warningCategoryInvalidMetric,
+					fmt.Errorf("%s: duplicate series after redaction", metric.Desc()))
+				continue
+			}
+
+			seen[key] = struct{}{}
+
+			ch <- redacted
+		}
+	}()
+
+	err := m.Member.Collect(ctx, collected)
+
+	close(collected)
+	wg.Wait()
+
+	return err
+}
@@ -0,0 +1,204 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hansmi/paperhooks/pkg/client"
+	"github.com/hansmi/prometheus-paperless-exporter/internal/testutil"
+)
+
+func TestRedactionConfigBuild(t *testing.T) {
+	saltFile := filepath.Join(t.TempDir(), "salt")
+
+	if err := os.WriteFile(saltFile, []byte("pepper\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		cfg     RedactionConfig
+		wantErr error
+	}{
+		{name: "drop", cfg: RedactionConfig{Action: "drop"}},
+		{name: "hash", cfg: RedactionConfig{Action: "hash", Salt: "x"}},
+		{name: "hash with salt file", cfg: RedactionConfig{Action: "hash", SaltFile: saltFile}},
+		{
+			name:    "hash without salt",
+			cfg:     RedactionConfig{Action: "hash"},
+			wantErr: cmpopts.AnyError,
+		},
+		{
+			name:    "missing salt file",
+			cfg:     RedactionConfig{Action: "hash", SaltFile: filepath.Join(t.TempDir(), "missing")},
+			wantErr: os.ErrNotExist,
+		},
+		{name: "truncate", cfg: RedactionConfig{Action: "truncate", Length: 3}},
+		{
+			name:    "truncate without length",
+			cfg:     RedactionConfig{Action: "truncate"},
+			wantErr: cmpopts.AnyError,
+		},
+		{name: "allow", cfg: RedactionConfig{Action: "allow", Allow: "[a-z]+"}},
+		{
+			name:    "allow with invalid pattern",
+			cfg:     RedactionConfig{Action: "allow", Allow: "["},
+			wantErr: cmpopts.AnyError,
+		},
+		{
+			name:    "unknown action",
+			cfg:     RedactionConfig{Action: "encrypt"},
+			wantErr: cmpopts.AnyError,
+		},
+		{name: "empty", wantErr: cmpopts.AnyError},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.cfg.Build()
+
+			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Error diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRedactionPolicyApply(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		cfg   RedactionConfig
+		value string
+		want  string
+	}{
+		{name: "drop", cfg: RedactionConfig{Action: "drop"}, value: "secret", want: ""},
+		{name: "drop empty", cfg: RedactionConfig{Action: "drop"}, value: "", want: ""},
+		{
+			name:  "hash",
+			cfg:   RedactionConfig{Action: "hash", Salt: "pepper"},
+			value: "Dr. Smith",
+			want:  "6ab22814152d721d",
+		},
+		{
+			name:  "hash with other salt",
+			cfg:   RedactionConfig{Action: "hash", Salt: "salt"},
+			value: "Dr. Smith",
+			want:  "1323cc2d30e6c137",
+		},
+		{name: "hash empty", cfg: RedactionConfig{Action: "hash", Salt: "x"}, value: "", want: ""},
+		{name: "truncate", cfg: RedactionConfig{Action: "truncate", Length: 4}, value: "Lawyers Inc.", want: "Lawy"},
+		{name: "truncate runes", cfg: RedactionConfig{Action: "truncate", Length: 2}, value: "äöü", want: "äö"},
+		{name: "truncate short", cfg: RedactionConfig{Action: "truncate", Length: 20}, value: "short", want: "short"},
+		{name: "allow match", cfg: RedactionConfig{Action: "allow", Allow: "bank|utility"}, value: "bank", want: "bank"},
+		{name: "allow partial", cfg: RedactionConfig{Action: "allow", Allow: "bank|utility"}, value: "bank of x", want: ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := tc.cfg.Build()
+			if err != nil {
+				t.Fatalf("Build() failed: %v", err)
+			}
+
+			if got := p.apply(tc.value); got != tc.want {
+				t.Errorf("apply(%q) = %q, want %q", tc.value, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRedactingMember(t *testing.T) {
+	allow, err := RedactionConfig{Action: "allow", Allow: "public.*"}.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	drop, err := RedactionConfig{Action: "drop"}.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cl := fakeTagClient{
+		items: []client.Tag{
+			{ID: 1, Name: "public", Slug: "p"},
+			{ID: 2, Name: "medical", Slug: "m", DocumentCount: 3},
+			{ID: 3, Name: "legal", Slug: "l", DocumentCount: 4},
+		},
+	}
+
+	c := newMultiCollectorForTest(t, newRedactingMember(newTagCollector(&cl), map[string]*RedactionPolicy{
+		"name": allow,
+		"slug": drop,
+	}))
+
+	testutil.CollectAndCompare(t, c, `
+# HELP paperless_tag_document_count Number of documents associated with a tag.
+# TYPE paperless_tag_document_count gauge
+paperless_tag_document_count{id="1"} 0
+paperless_tag_document_count{id="2"} 3
+paperless_tag_document_count{id="3"} 4
+# HELP paperless_tag_info Static information about a tag.
+# TYPE paperless_tag_info gauge
+paperless_tag_info{id="1",name="public",slug=""} 1
+paperless_tag_info{id="2",name="",slug=""} 1
+paperless_tag_info{id="3",name="",slug=""} 1
+# HELP paperless_warnings_total Number of warnings generated while scraping metrics.
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
+paperless_warnings_total{category="unspecified"} 0
+`, "paperless_tag_document_count", "paperless_tag_info", "paperless_warnings_total")
+
+	// Series indistinguishable after redaction are reported once.
+	c = newMultiCollectorForTest(t, newRedactingMember(newTagCollector(&cl), map[string]*RedactionPolicy{
+		"id": drop,
+	}))
+
+	testutil.CollectAndCompare(t, c, `
+# HELP paperless_tag_inbox Whether the tag is marked as an inbox tag.
+# TYPE paperless_tag_inbox gauge
+paperless_tag_inbox{id=""} 0
+# HELP paperless_warnings_total Number of warnings generated while scraping metrics.
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 4
+paperless_warnings_total{category="timeout"} 0
+paperless_warnings_total{category="unspecified"} 0
+`, "paperless_tag_inbox", "paperless_warnings_total")
+}
+
+func TestRedactingMemberWarnings(t *testing.T) {
+	drop, err := RedactionConfig{Action: "drop"}.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cl := fakeTagClient{
+		items: []client.Tag{
+			{ID: 1, Name: "Secret Clinic\xff"},
+			{ID: 1, Name: "Secret Lawyer"},
+		},
+	}
+
+	c := newMultiCollectorForTest(t, newRedactingMember(newTagCollector(&cl), map[string]*RedactionPolicy{
+		"name": drop,
+	}))
+	c.ids = []string{"tag"}
+
+	testutil.CollectAndCompare(t, c, "", "paperless_unknown")
+
+	warnings := c.RecentWarnings()
+
+	if len(warnings) == 0 {
+		t.Errorf("No warnings recorded")
+	}
+
+	for _, w := range warnings {
+		if strings.Contains(w.Message, "Secret") {
+			t.Errorf("Warning message %q contains a redacted label value", w.Message)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"sync"
+
+	"github.com/hansmi/paperhooks/pkg/client"
+)
+
+// Info describes a collector available for selection.
+type Info struct {
+	// Whether the collector is enabled unless deselected explicitly.
+	DefaultEnabled bool
+
+	// Paperless object types on which the metrics user requires view
+	// permissions, e.g. "Document" or "Tag".
+	Permissions []string
+
+	// New builds the collector for the given client.
+	New func(*client.Client) Member
+
+	// Whether the collector supports [MemberOptions.CardinalityLimit]. Only
+	// built-in collectors do.
+	cardinalityLimit bool
+}
+
+var idPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+var (
+	registryMu      sync.RWMutex
+	knownCollectors = map[string]Info{
+		"tag": {
+			DefaultEnabled:   true,
+			Permissions:      []string{"Tag"},
+			New:              func(c *client.Client) Member { return newTagCollector(c) },
+			cardinalityLimit: true,
+		},
+		"correspondent": {
+			DefaultEnabled:   true,
+			Permissions:      []string{"Correspondent"},
+			New:              func(c *client.Client) Member { return newCorrespondentCollector(c) },
+			cardinalityLimit: true,
+		},
+		"document_type": {
+			DefaultEnabled:   true,
+			Permissions:      []string{"DocumentType"},
+			New:              func(c *client.Client) Member { return newDocumentTypeCollector(c) },
+			cardinalityLimit: true,
+		},
+		"storage_path": {
+			DefaultEnabled:   true,
+			Permissions:      []string{"StoragePath"},
+			New:              func(c *client.Client) Member { return newStoragePathCollector(c) },
+			cardinalityLimit: true,
+		},
+		"task": {
+			DefaultEnabled: true,
+			Permissions:    []string{"PaperlessTask"},
+			New:            func(c *client.Client) Member { return newTaskCollector(c) },
+		},
+		"log": {
+			DefaultEnabled: true,
+			Permissions:    []string{"Admin"},
+			New:            func(c *client.Client) Member { return newLogCollector(c) },
+		},
+		"group": {
+			DefaultEnabled: true,
+			Permissions:    []string{"Group"},
+			New:            func(c *client.Client) Member { return newGroupCollector(c) },
+		},
+		"user": {
+			DefaultEnabled: true,
+			Permissions:    []string{"User"},
+			New:            func(c *client.Client) Member { return newUserCollector(c) },
+		},
+		"document": {
+			DefaultEnabled: true,
+			Permissions:    []string{"Document"},
+			New:            func(c *client.Client) Member { return newDocumentCollector(c) },
+		},
+		"status": {
+			DefaultEnabled: true,
+			New:            func(c *client.Client) Member { return newStatusCollector(c) },
+		},
+		"statistics": {
+			DefaultEnabled: true,
+			Permissions:    []string{"Document"},
+			New:            func(c *client.Client) Member { return newStatisticsCollector(c) },
+		},
+		"remote_version": {
+			// Depends on public internet access of the Paperless instance.
+			DefaultEnabled: false,
+			New:            func(c *client.Client) Member { return newRemoteVersionCollector(c) },
+		},
+	}
+)
+
+// Register makes a collector available for selection under the given ID.
+// IDs consist of lowercase letters, digits and underscores. Collectors must be
+// registered before any are built, usually from an init function, so that
+// they're also available as command line flags.
+func Register(id string, info Info) error {
+	if !idPattern.MatchString(id) {
+		return fmt.Errorf("invalid collector ID %q", id)
+	}
+
+	if info.New == nil {
+		return errors.New("collector constructor is missing")
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, ok := knownCollectors[id]; ok {
+		return fmt.Errorf("collector already registered: %s", id)
+	}
+
+	info.Permissions = slices.Clone(info.Permissions)
+
+	knownCollectors[id] = info
+
+	return nil
+}
+
+// MustRegister is like [Register], but panics on errors.
+func MustRegister(id string, info Info) {
+	if err := Register(id, info); err != nil {
+		panic(err)
+	}
+}
+
+// IDs returns the sorted IDs of all known collectors.
+func IDs() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	return slices.Sorted(maps.Keys(knownCollectors))
+}
+
+// Lookup returns the collector with the given ID.
+func Lookup(id string) (Info, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	info, ok := knownCollectors[id]
+
+	return info, ok
+}
+
+// CheckIDs verifies that all given IDs refer to known collectors.
+func CheckIDs(ids []string) error {
+	for _, id := range ids {
+		if _, ok := Lookup(id); !ok {
+			return fmt.Errorf("unknown collector: %s", id)
+		}
+	}
+
+	return nil
+}
+
+// Permissions returns the sorted Paperless object types on which view
+// permissions are required by the given collectors.
+func Permissions(ids []string) []string {
+	var result []string
+
+	for _, id := range ids {
+		if info, ok := Lookup(id); ok {
+			result = append(result, info.Permissions...)
+		}
+	}
+
+	slices.Sort(result)
+
+	return slices.Compact(result)
+}
@@ -0,0 +1,146 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hansmi/paperhooks/pkg/client"
+	"github.com/hansmi/prometheus-paperless-exporter/internal/testutil"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type costCenterMember struct {
+	desc *prometheus.Desc
+}
+
+func (m *costCenterMember) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.desc
+}
+
+func (m *costCenterMember) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	ch <- prometheus.MustNewConstMetric(m.desc, prometheus.GaugeValue, 42, "cc-1000")
+	return nil
+}
+
+func registerForTest(t *testing.T, id string, info Info) {
+	t.Helper()
+
+	if err := Register(id, info); err != nil {
+		t.Fatalf("Register(%q) failed: %v", id, err)
+	}
+
+	t.Cleanup(func() {
+		registryMu.Lock()
+		defer registryMu.Unlock()
+
+		delete(knownCollectors, id)
+	})
+}
+
+func TestRegister(t *testing.T) {
+	newMember := func(*client.Client) Member {
+		return &costCenterMember{
+			desc: prometheus.NewDesc("acme_cost_center_documents",
+				"Number of documents per cost center.",
+				[]string{"cost_center"}, nil),
+		}
+	}
+
+	registerForTest(t, "acme_cost_center", Info{
+		Permissions: []string{"Document", "CustomField"},
+		New:         newMember,
+	})
+
+	for _, tc := range []struct {
+		name    string
+		id      string
+		info    Info
+		wantErr error
+	}{
+		{name: "duplicate", id: "acme_cost_center", info: Info{New: newMember}, wantErr: cmpopts.AnyError},
+		{name: "builtin", id: "tag", info: Info{New: newMember}, wantErr: cmpopts.AnyError},
+		{name: "empty ID", info: Info{New: newMember}, wantErr: cmpopts.AnyError},
+		{name: "invalid ID", id: "Cost-Center", info: Info{New: newMember}, wantErr: cmpopts.AnyError},
+		{name: "missing constructor", id: "acme_other", wantErr: cmpopts.AnyError},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Register(tc.id, tc.info)
+
+			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Error diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if err := CheckIDs([]string{"acme_cost_center"}); err != nil {
+		t.Errorf("CheckIDs() failed: %v", err)
+	}
+
+	// Not enabled by default.
+	c, err := New(nil, Options{
+		Overrides: map[string]bool{
+			"acme_cost_center": true,
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	c, err = c.Filter([]string{"acme_cost_center"}, nil)
+	if err != nil {
+		t.Fatalf("Filter() failed: %v", err)
+	}
+
+	testutil.CollectAndCompare(t, c, `
+# HELP acme_cost_center_documents Number of documents per cost center.
+# TYPE acme_cost_center_documents gauge
+acme_cost_center_documents{cost_center="cc-1000"} 42
+`, "acme_cost_center_documents")
+}
+
+func TestPermissions(t *testing.T) {
+	registerForTest(t, "acme_test", Info{
+		Permissions: []string{"CustomField", "Tag"},
+		New:         func(*client.Client) Member { return &costCenterMember{} },
+	})
+
+	got := Permissions([]string{"tag", "acme_test", "status", "unknown"})
+
+	if diff := cmp.Diff([]string{"CustomField", "Tag"}, got); diff != "" {
+		t.Errorf("Permissions() diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestSupportsCardinalityLimit(t *testing.T) {
+	registerForTest(t, "acme_panicking", Info{
+		New: func(*client.Client) Member {
+			panic("constructor called")
+		},
+	})
+
+	if SupportsCardinalityLimit("acme_panicking") {
+		t.Errorf("SupportsCardinalityLimit() reports support for a registered collector")
+	}
+
+	if SupportsCardinalityLimit("unknown") {
+		t.Errorf("SupportsCardinalityLimit() reports support for an unknown collector")
+	}
+
+	// The declared capability matches the implementation of the built-in
+	// collectors.
+	for _, id := range IDs() {
+		if id == "acme_panicking" {
+			continue
+		}
+
+		info, _ := Lookup(id)
+
+		_, want := info.New(nil).(cardinalityLimiter)
+
+		if got := SupportsCardinalityLimit(id); got != want {
+			t.Errorf("SupportsCardinalityLimit(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package collector
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/hansmi/prometheus-paperless-exporter/pkg/relabel"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+// relabelingGatherer applies relabeling rules to all gathered series before
+// exposition. Series dropped by the rules are omitted. Series conflicting with
+// an earlier series after relabeling, or renamed into a family of a different
+// type, are omitted as well.
+type relabelingGatherer struct {
+	prometheus.Gatherer
+
+	configs []*relabel.Config
+}
+
+var _ prometheus.Gatherer = (*relabelingGatherer)(nil)
+
+func newRelabelingGatherer(g prometheus.Gatherer, configs []*relabel.Config) prometheus.Gatherer {
+	if len(configs) == 0 {
+		return g
+	}
+
+	return &relabelingGatherer{Gatherer: g, configs: configs}
+}
+
+func (g *relabelingGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := g.Gatherer.Gather()
+
+	result := map[string]*dto.MetricFamily{}
+	seen := map[string]struct{}{}
+
+	for _, mf := range families {
+		for _, m := range mf.Metric {
+			labels := map[string]string{
+				relabel.MetricNameLabel: mf.GetName(),
+			}
+
+			for _, lp := range m.Label {
+				labels[lp.GetName()] = lp.GetValue()
+			}
+
+			labels = relabel.Process(labels, g.configs...)
+			if labels == nil {
+				continue
+			}
+
+			name := labels[relabel.MetricNameLabel]
+			delete(labels, relabel.MetricNameLabel)
+
+			if !relabel.IsValidLabelName(name) {
+				continue
+			}
+
+			out := result[name]
+
+			if out == nil {
+				out = &dto.MetricFamily{
+					Name: proto.String(name),
+					Help: mf.Help,
+					Type: mf.Type,
+					Unit: mf.Unit,
+				}
+				result[name] = out
+			} else if out.GetType() != mf.GetType() {
+				continue
+			}
+
+			names := slices.Sorted(maps.Keys(labels))
+
+			var key strings.Builder
+
+			key.WriteString(name)
+
+			for _, k := range names {
+				key.WriteString("\xff" + k + "=" + labels[k])
+			}
+
+			if _, ok := seen[key.String()]; ok {
+				continue
+			}
+
+			seen[key.String()] = struct{}{}
+
+			relabeled := proto.Clone(m).(*dto.Metric)
+			relabeled.Label = nil
+
+			for _, k := range names {
+				relabeled.Label = append(relabeled.Label, &dto.LabelPair{
+					Name:  proto.String(k),
+					Value: proto.String(labels[k]),
+				})
+			}
+
+			out.Metric = append(out.Metric, relabeled)
+		}
+	}
+
+	var output []*dto.MetricFamily
+
+	for _, mf := range result {
+		slices.SortFunc(mf.Metric, compareLabels)
+		output = append(output, mf)
+	}
+
+	slices.SortFunc(output, func(a, b *dto.MetricFamily) int {
+		return cmp.Compare(a.GetName(), b.GetName())
+	})
+
+	return output, err
+}
+
+// compareLabels orders metrics by their sorted label pairs.
+func compareLabels(a, b *dto.Metric) int {
+	for idx := range min(len(a.Label), len(b.Label)) {
+		if c := cmp.Compare(a.Label[idx].GetName(), b.Label[idx].GetName()); c != 0 {
+			return c
+		}
+
+		if c := cmp.Compare(a.Label[idx].GetValue(), b.Label[idx].GetValue()); c != 0 {
+			return c
+		}
+	}
+
+	return cmp.Compare(len(a.Label), len(b.Label))
+}
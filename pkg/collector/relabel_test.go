@@ -0,0 +1,95 @@
+package collector
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hansmi/prometheus-paperless-exporter/pkg/relabel"
+	"github.com/prometheus/client_golang/prometheus"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRelabelingGatherer(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+
+	info := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "paperless_tag_info",
+		Help: "Static information about a tag.",
+	}, []string{"id", "name"})
+	info.WithLabelValues("1", "Invoices").Set(1)
+	info.WithLabelValues("2", "Medical").Set(1)
+	info.WithLabelValues("3", "Taxes").Set(1)
+
+	count := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "paperless_tag_document_count",
+		Help: "Number of documents associated with a tag.",
+	}, []string{"id"})
+	count.WithLabelValues("1").Set(10)
+
+	inbox := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "paperless_tag_inbox",
+		Help: "Whether the tag is marked as an inbox tag.",
+	})
+
+	reg.MustRegister(info, count, inbox)
+
+	g := newRelabelingGatherer(reg, []*relabel.Config{
+		{
+			SourceLabels: []string{"__name__"},
+			Regex:        relabel.MustNewRegexp("paperless_tag_inbox"),
+			Action:       relabel.Drop,
+		},
+		{
+			SourceLabels: []string{"name"},
+			Regex:        relabel.MustNewRegexp("Medical"),
+			Action:       relabel.Drop,
+		},
+		{
+			SourceLabels: []string{"name"},
+			Regex:        relabel.MustNewRegexp("(.*)"),
+			TargetLabel:  "tag",
+			Replacement:  "$1",
+			Action:       relabel.Replace,
+		},
+		{
+			Regex:  relabel.MustNewRegexp("name"),
+			Action: relabel.LabelDrop,
+		},
+	})
+
+	if err := promtestutil.GatherAndCompare(g, strings.NewReader(`
+# HELP paperless_tag_document_count Number of documents associated with a tag.
+# TYPE paperless_tag_document_count gauge
+paperless_tag_document_count{id="1"} 10
+# HELP paperless_tag_info Static information about a tag.
+# TYPE paperless_tag_info gauge
+paperless_tag_info{id="1",tag="Invoices"} 1
+paperless_tag_info{id="3",tag="Taxes"} 1
+`)); err != nil {
+		t.Error(err)
+	}
+
+	// Series without distinguishing labels are reported once.
+	g = newRelabelingGatherer(reg, []*relabel.Config{
+		{
+			Regex:  relabel.MustNewRegexp("id|name"),
+			Action: relabel.LabelDrop,
+		},
+	})
+
+	if err := promtestutil.GatherAndCompare(g, strings.NewReader(`
+# HELP paperless_tag_info Static information about a tag.
+# TYPE paperless_tag_info gauge
+paperless_tag_info 1
+`), "paperless_tag_info"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRelabelingGathererWithoutConfigs(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	if got := newRelabelingGatherer(reg, nil); got != reg {
+		t.Errorf("newRelabelingGatherer() = %v, want unmodified gatherer", got)
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package collector
 
 import (
 	"context"
@@ -8,10 +8,6 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-const (
-	remoteVersionCollectorID = "remote_version"
-)
-
 type remoteVersionClient interface {
 	GetRemoteVersion(ctx context.Context) (*client.RemoteVersion, *client.Response, error)
 }
@@ -30,16 +26,19 @@ func newRemoteVersionCollector(cl remoteVersionClient) *remoteVersionCollector {
 	}
 }
 
-func (c *remoteVersionCollector) describe(ch chan<- *prometheus.Desc) {
+func (c *remoteVersionCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.updateAvailableDesc
 }
 
-func (c *remoteVersionCollector) collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+func (c *remoteVersionCollector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	b := newMetricBuilder(ch)
+
 	var updateAvailable float64
 	var version string
 
 	if remoteVersion, _, err := c.cl.GetRemoteVersion(ctx); err != nil {
-		ch <- newWarning(warningCategoryGetRemoteVersion, fmt.Errorf("fetching remote version: %w", err))
+		ch <- newWarning(warningCategoryGetRemoteVersion, fmt.Errorf("fetching remote version: %w", err)).
+			withEndpoint("/api/remote_version/")
 	} else {
 		version = remoteVersion.Version
 
@@ -48,7 +47,7 @@ func (c *remoteVersionCollector) collect(ctx context.Context, ch chan<- promethe
 		}
 	}
 
-	ch <- prometheus.MustNewConstMetric(c.updateAvailableDesc, prometheus.GaugeValue, updateAvailable, version)
+	b.gauge(c.updateAvailableDesc, updateAvailable, version)
 
 	return nil
 }
@@ -1,4 +1,4 @@
-package main
+package collector
 
 import (
 	"context"
@@ -50,7 +50,7 @@ func TestRemoteVersion(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			c := newRemoteVersionCollector(&tc.cl)
 
-			err := c.collect(context.Background(), testutil.DiscardMetrics(t))
+			err := c.Collect(context.Background(), testutil.DiscardMetrics(t))
 
 			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Errorf("Error diff (-want +got):\n%s", diff)
@@ -80,7 +80,11 @@ func TestRemoteVersionCollect(t *testing.T) {
 # TYPE paperless_remote_version_update_available gauge
 paperless_remote_version_update_available{version="1.2.3"} 1
 # HELP paperless_warnings_total Number of warnings generated while scraping metrics.
-# TYPE paperless_warnings_total gauge
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
 paperless_warnings_total{category="unspecified"} 0
 `,
 		},
@@ -97,7 +101,11 @@ paperless_warnings_total{category="unspecified"} 0
 # TYPE paperless_remote_version_update_available gauge
 paperless_remote_version_update_available{version="1.2.3"} 0
 # HELP paperless_warnings_total Number of warnings generated while scraping metrics.
-# TYPE paperless_warnings_total gauge
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
 paperless_warnings_total{category="unspecified"} 0
 `,
 		},
@@ -113,7 +121,11 @@ paperless_warnings_total{category="unspecified"} 0
 # TYPE paperless_remote_version_update_available gauge
 paperless_remote_version_update_available{version=""} 1
 # HELP paperless_warnings_total Number of warnings generated while scraping metrics.
-# TYPE paperless_warnings_total gauge
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
 paperless_warnings_total{category="unspecified"} 0
 `,
 		},
@@ -126,9 +138,15 @@ paperless_warnings_total{category="unspecified"} 0
 # HELP paperless_remote_version_update_available Whether an update is available.
 # TYPE paperless_remote_version_update_available gauge
 paperless_remote_version_update_available{version=""} 0
+# HELP paperless_warning_last_timestamp_seconds Number of seconds since 1970 of the last warning in a category.
+# TYPE paperless_warning_last_timestamp_seconds gauge
+paperless_warning_last_timestamp_seconds{category="get_remote_version"} 1.5778368e+09
 # HELP paperless_warnings_total Number of warnings generated while scraping metrics.
-# TYPE paperless_warnings_total gauge
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
 paperless_warnings_total{category="get_remote_version"} 1
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
 paperless_warnings_total{category="unspecified"} 0
 `,
 		},
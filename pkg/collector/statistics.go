@@ -1,4 +1,4 @@
-package main
+package collector
 
 import (
 	"context"
@@ -41,7 +41,7 @@ func newStatisticsCollector(cl statisticsClient) *statisticsCollector {
 	}
 }
 
-func (c *statisticsCollector) describe(ch chan<- *prometheus.Desc) {
+func (c *statisticsCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.documentsTotalDesc
 	ch <- c.documentsInboxDesc
 	ch <- c.documentFileTypeCountsDesc
@@ -52,24 +52,26 @@ func (c *statisticsCollector) describe(ch chan<- *prometheus.Desc) {
 	ch <- c.storagePathCountDesc
 }
 
-func (c *statisticsCollector) collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+func (c *statisticsCollector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	b := newMetricBuilder(ch)
+
 	statistics, _, err := c.cl.GetStatistics(ctx)
 	if err != nil {
 		return err
 	}
 
-	ch <- prometheus.MustNewConstMetric(c.documentsTotalDesc, prometheus.GaugeValue, float64(statistics.DocumentsTotal))
-	ch <- prometheus.MustNewConstMetric(c.documentsInboxDesc, prometheus.GaugeValue, float64(statistics.DocumentsInbox))
+	b.gauge(c.documentsTotalDesc, float64(statistics.DocumentsTotal))
+	b.gauge(c.documentsInboxDesc, float64(statistics.DocumentsInbox))
 
 	for _, documentFileTypeCount := range statistics.DocumentFileTypeCounts {
-		ch <- prometheus.MustNewConstMetric(c.documentFileTypeCountsDesc, prometheus.GaugeValue, float64(documentFileTypeCount.MimeTypeCount), documentFileTypeCount.MimeType)
+		b.gauge(c.documentFileTypeCountsDesc, float64(documentFileTypeCount.MimeTypeCount), documentFileTypeCount.MimeType)
 	}
 
-	ch <- prometheus.MustNewConstMetric(c.characterCountDesc, prometheus.GaugeValue, float64(statistics.CharacterCount))
-	ch <- prometheus.MustNewConstMetric(c.tagCountDesc, prometheus.GaugeValue, float64(statistics.TagCount))
-	ch <- prometheus.MustNewConstMetric(c.correspondentCountDesc, prometheus.GaugeValue, float64(statistics.CorrespondentCount))
-	ch <- prometheus.MustNewConstMetric(c.documentTypeCountDesc, prometheus.GaugeValue, float64(statistics.DocumentTypeCount))
-	ch <- prometheus.MustNewConstMetric(c.storagePathCountDesc, prometheus.GaugeValue, float64(statistics.StoragePathCount))
+	b.gauge(c.characterCountDesc, float64(statistics.CharacterCount))
+	b.gauge(c.tagCountDesc, float64(statistics.TagCount))
+	b.gauge(c.correspondentCountDesc, float64(statistics.CorrespondentCount))
+	b.gauge(c.documentTypeCountDesc, float64(statistics.DocumentTypeCount))
+	b.gauge(c.storagePathCountDesc, float64(statistics.StoragePathCount))
 
 	return nil
 }
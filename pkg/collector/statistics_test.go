@@ -1,4 +1,4 @@
-package main
+package collector
 
 import (
 	"context"
@@ -69,7 +69,7 @@ func TestStatistics(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			c := newStatisticsCollector(&tc.cl)
 
-			err := c.collect(context.Background(), testutil.DiscardMetrics(t))
+			err := c.Collect(context.Background(), testutil.DiscardMetrics(t))
 
 			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Errorf("Error diff (-want +got):\n%s", diff)
@@ -110,7 +110,11 @@ paperless_statistics_storage_path_count 0
 # TYPE paperless_statistics_tag_count gauge
 paperless_statistics_tag_count 55
 # HELP paperless_warnings_total Number of warnings generated while scraping metrics.
-# TYPE paperless_warnings_total gauge
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
 paperless_warnings_total{category="unspecified"} 0
 `)
 }
@@ -1,4 +1,4 @@
-package main
+package collector
 
 import (
 	"context"
@@ -48,7 +48,7 @@ func newStatusCollector(cl statusClient) *statusCollector {
 	}
 }
 
-func (c *statusCollector) describe(ch chan<- *prometheus.Desc) {
+func (c *statusCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.storageTotalDesc
 	ch <- c.storageAvailableDesc
 	ch <- c.databaseStatusDesc
@@ -63,24 +63,26 @@ func (c *statusCollector) describe(ch chan<- *prometheus.Desc) {
 	ch <- c.sanityCheckLastRunDesc
 }
 
-func (c *statusCollector) collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+func (c *statusCollector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	b := newMetricBuilder(ch)
+
 	status, _, err := c.cl.GetStatus(ctx)
 	if err != nil {
 		return err
 	}
 
-	ch <- prometheus.MustNewConstMetric(c.storageTotalDesc, prometheus.GaugeValue, float64(status.Storage.Total))
-	ch <- prometheus.MustNewConstMetric(c.storageAvailableDesc, prometheus.GaugeValue, float64(status.Storage.Available))
-	ch <- prometheus.MustNewConstMetric(c.databaseStatusDesc, prometheus.GaugeValue, c.isOK(status.Database.Status))
-	ch <- prometheus.MustNewConstMetric(c.databaseUnappliedMigrationsDesc, prometheus.GaugeValue, float64(len(status.Database.MigrationStatus.UnappliedMigrations)))
-	ch <- prometheus.MustNewConstMetric(c.redisStatusDesc, prometheus.GaugeValue, c.isOK(status.Tasks.RedisStatus))
-	ch <- prometheus.MustNewConstMetric(c.celeryStatusDesc, prometheus.GaugeValue, c.isOK(status.Tasks.CeleryStatus))
-	ch <- prometheus.MustNewConstMetric(c.indexStatusDesc, prometheus.GaugeValue, c.isOK(status.Tasks.IndexStatus))
-	ch <- prometheus.MustNewConstMetric(c.indexLastModifiedDesc, prometheus.GaugeValue, float64(status.Tasks.IndexLastModified.Unix()))
-	ch <- prometheus.MustNewConstMetric(c.classifierStatusDesc, prometheus.GaugeValue, c.isOK(status.Tasks.ClassifierStatus))
-	ch <- prometheus.MustNewConstMetric(c.classifierLastTrainedDesc, prometheus.GaugeValue, float64(status.Tasks.ClassifierLastTrained.Unix()))
-	ch <- prometheus.MustNewConstMetric(c.sanityCheckStatusDesc, prometheus.GaugeValue, c.isOK(status.Tasks.SanityCheckStatus))
-	ch <- prometheus.MustNewConstMetric(c.sanityCheckLastRunDesc, prometheus.GaugeValue, float64(status.Tasks.SanityCheckLastRun.Unix()))
+	b.gauge(c.storageTotalDesc, float64(status.Storage.Total))
+	b.gauge(c.storageAvailableDesc, float64(status.Storage.Available))
+	b.gauge(c.databaseStatusDesc, c.isOK(status.Database.Status))
+	b.gauge(c.databaseUnappliedMigrationsDesc, float64(len(status.Database.MigrationStatus.UnappliedMigrations)))
+	b.gauge(c.redisStatusDesc, c.isOK(status.Tasks.RedisStatus))
+	b.gauge(c.celeryStatusDesc, c.isOK(status.Tasks.CeleryStatus))
+	b.gauge(c.indexStatusDesc, c.isOK(status.Tasks.IndexStatus))
+	b.gauge(c.indexLastModifiedDesc, float64(status.Tasks.IndexLastModified.Unix()))
+	b.gauge(c.classifierStatusDesc, c.isOK(status.Tasks.ClassifierStatus))
+	b.gauge(c.classifierLastTrainedDesc, float64(status.Tasks.ClassifierLastTrained.Unix()))
+	b.gauge(c.sanityCheckStatusDesc, c.isOK(status.Tasks.SanityCheckStatus))
+	b.gauge(c.sanityCheckLastRunDesc, float64(status.Tasks.SanityCheckLastRun.Unix()))
 
 	return nil
 }
@@ -0,0 +1,101 @@
+package collector
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/hansmi/paperhooks/pkg/client"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type storagePathClient interface {
+	ListStoragePaths(context.Context, client.ListStoragePathsOptions) ([]client.StoragePath, *client.Response, error)
+}
+
+type storagePathCollector struct {
+	cl storagePathClient
+
+	// Maximum number of storage paths reported individually. Zero disables the
+	// limit.
+	limit int
+
+	infoDesc     *prometheus.Desc
+	docCountDesc *prometheus.Desc
+	foldedDesc   *prometheus.Desc
+}
+
+func newStoragePathCollector(cl storagePathClient) *storagePathCollector {
+	return &storagePathCollector{
+		cl: cl,
+
+		infoDesc: prometheus.NewDesc("paperless_storage_path_info",
+			"Static information about a storage path.",
+			[]string{"id", "name", "slug"}, nil),
+		docCountDesc: prometheus.NewDesc("paperless_storage_path_document_count",
+			"Number of documents associated with a storage path.",
+			[]string{"id"}, nil),
+		foldedDesc: prometheus.NewDesc("paperless_storage_path_folded_objects",
+			`Number of storage paths folded into the id="other" aggregate by the cardinality limit.`,
+			nil, nil),
+	}
+}
+
+func (c *storagePathCollector) setCardinalityLimit(limit int) {
+	c.limit = limit
+}
+
+func (c *storagePathCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.infoDesc
+	ch <- c.docCountDesc
+	ch <- c.foldedDesc
+}
+
+func (c *storagePathCollector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	b := newMetricBuilder(ch)
+
+	var opts client.ListStoragePathsOptions
+
+	opts.Ordering.Field = "name"
+
+	fetch := func(ctx context.Context, page *client.PageOptions) ([]client.StoragePath, *client.Response, error) {
+		pageOpts := opts
+		pageOpts.Page = page
+
+		return c.cl.ListStoragePaths(ctx, pageOpts)
+	}
+
+	docCount := func(sp client.StoragePath) int64 {
+		return sp.DocumentCount
+	}
+
+	emit := func(sp client.StoragePath) {
+		id := strconv.FormatInt(sp.ID, 10)
+
+		b.gauge(c.infoDesc, 1,
+			id,
+			sp.Name,
+			sp.Slug,
+		)
+
+		b.gauge(c.docCountDesc, float64(sp.DocumentCount), id)
+	}
+
+	fold := func(paths []client.StoragePath) {
+		b.gauge(c.foldedDesc, float64(len(paths)))
+
+		if len(paths) == 0 {
+			return
+		}
+
+		var total int64
+
+		for _, sp := range paths {
+			total += sp.DocumentCount
+		}
+
+		b.gauge(c.infoDesc, 1, foldedObjectID, "", "")
+		b.gauge(c.docCountDesc, float64(total), foldedObjectID)
+	}
+
+	return collectTopObjects(ctx, fetch, c.limit, docCount, emit, fold)
+}
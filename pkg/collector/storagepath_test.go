@@ -1,4 +1,4 @@
-package main
+package collector
 
 import (
 	"context"
@@ -16,14 +16,8 @@ type fakeStoragePathClient struct {
 	err   error
 }
 
-func (c *fakeStoragePathClient) ListAllStoragePaths(ctx context.Context, opts client.ListStoragePathsOptions, handler func(context.Context, client.StoragePath) error) error {
-	for _, i := range c.items {
-		if err := handler(ctx, i); err != nil {
-			return err
-		}
-	}
-
-	return c.err
+func (c *fakeStoragePathClient) ListStoragePaths(ctx context.Context, opts client.ListStoragePathsOptions) ([]client.StoragePath, *client.Response, error) {
+	return fakeListPage(c.items, c.err, opts.Page)
 }
 
 func TestStoragePath(t *testing.T) {
@@ -58,7 +52,7 @@ func TestStoragePath(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			c := newStoragePathCollector(&tc.cl)
 
-			err := c.collect(context.Background(), testutil.DiscardMetrics(t))
+			err := c.Collect(context.Background(), testutil.DiscardMetrics(t))
 
 			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Errorf("Error diff (-want +got):\n%s", diff)
@@ -74,7 +68,11 @@ func TestStoragePathCollect(t *testing.T) {
 
 	testutil.CollectAndCompare(t, c, `
 # HELP paperless_warnings_total Number of warnings generated while scraping metrics.
-# TYPE paperless_warnings_total gauge
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
 paperless_warnings_total{category="unspecified"} 0
 `)
 
@@ -93,7 +91,11 @@ paperless_storage_path_document_count{id="704"} 13
 paperless_storage_path_info{id="23547",name="personal",slug="personal"} 1
 paperless_storage_path_info{id="704",name="work",slug=""} 1
 # HELP paperless_warnings_total Number of warnings generated while scraping metrics.
-# TYPE paperless_warnings_total gauge
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
 paperless_warnings_total{category="unspecified"} 0
 `)
 }
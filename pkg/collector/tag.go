@@ -0,0 +1,120 @@
+package collector
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/hansmi/paperhooks/pkg/client"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type tagClient interface {
+	ListTags(context.Context, client.ListTagsOptions) ([]client.Tag, *client.Response, error)
+}
+
+type tagCollector struct {
+	cl tagClient
+
+	// Maximum number of tags reported individually. Zero disables the limit.
+	limit int
+
+	infoDesc     *prometheus.Desc
+	docCountDesc *prometheus.Desc
+	inboxDesc    *prometheus.Desc
+	foldedDesc   *prometheus.Desc
+}
+
+func newTagCollector(cl tagClient) *tagCollector {
+	return &tagCollector{
+		cl: cl,
+
+		infoDesc: prometheus.NewDesc("paperless_tag_info",
+			"Static information about a tag.",
+			[]string{"id", "name", "slug"}, nil),
+		docCountDesc: prometheus.NewDesc("paperless_tag_document_count",
+			"Number of documents associated with a tag.",
+			[]string{"id"}, nil),
+		inboxDesc: prometheus.NewDesc("paperless_tag_inbox",
+			"Whether the tag is marked as an inbox tag.",
+			[]string{"id"}, nil),
+		foldedDesc: prometheus.NewDesc("paperless_tag_folded_objects",
+			`Number of tags folded into the id="other" aggregate by the cardinality limit.`,
+			nil, nil),
+	}
+}
+
+func (c *tagCollector) setCardinalityLimit(limit int) {
+	c.limit = limit
+}
+
+func (c *tagCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.infoDesc
+	ch <- c.docCountDesc
+	ch <- c.inboxDesc
+	ch <- c.foldedDesc
+}
+
+func (c *tagCollector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	b := newMetricBuilder(ch)
+
+	var opts client.ListTagsOptions
+
+	opts.Ordering.Field = "name"
+
+	fetch := func(ctx context.Context, page *client.PageOptions) ([]client.Tag, *client.Response, error) {
+		pageOpts := opts
+		pageOpts.Page = page
+
+		return c.cl.ListTags(ctx, pageOpts)
+	}
+
+	docCount := func(tag client.Tag) int64 {
+		return tag.DocumentCount
+	}
+
+	emit := func(tag client.Tag) {
+		id := strconv.FormatInt(tag.ID, 10)
+
+		b.gauge(c.infoDesc, 1,
+			id,
+			tag.Name,
+			tag.Slug,
+		)
+
+		b.gauge(c.docCountDesc, float64(tag.DocumentCount), id)
+
+		isInboxTag := 0
+
+		if tag.IsInboxTag {
+			isInboxTag = 1
+		}
+
+		b.gauge(c.inboxDesc, float64(isInboxTag), id)
+	}
+
+	fold := func(tags []client.Tag) {
+		b.gauge(c.foldedDesc, float64(len(tags)))
+
+		if len(tags) == 0 {
+			return
+		}
+
+		var total int64
+
+		isInboxTag := 0
+
+		for _, tag := range tags {
+			total += tag.DocumentCount
+
+			if tag.IsInboxTag {
+				isInboxTag = 1
+			}
+		}
+
+		b.gauge(c.infoDesc, 1, foldedObjectID, "", "")
+		b.gauge(c.docCountDesc, float64(total), foldedObjectID)
+		b.gauge(c.inboxDesc, float64(isInboxTag), foldedObjectID)
+	}
+
+	return collectTopObjects(ctx, fetch, c.limit, docCount, emit, fold)
+}
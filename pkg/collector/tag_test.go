@@ -0,0 +1,169 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hansmi/paperhooks/pkg/client"
+	"github.com/hansmi/prometheus-paperless-exporter/internal/testutil"
+)
+
+type fakeTagClient struct {
+	items []client.Tag
+	err   error
+}
+
+func (c *fakeTagClient) ListTags(ctx context.Context, opts client.ListTagsOptions) ([]client.Tag, *client.Response, error) {
+	return fakeListPage(c.items, c.err, opts.Page)
+}
+
+func TestTag(t *testing.T) {
+	errTest := errors.New("test error")
+
+	for _, tc := range []struct {
+		name    string
+		cl      fakeTagClient
+		wantErr error
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "listing fails",
+			cl: fakeTagClient{
+				err: errTest,
+			},
+			wantErr: errTest,
+		},
+		{
+			name: "tags",
+			cl: fakeTagClient{
+				items: []client.Tag{
+					{ID: 8463},
+					{ID: 8463},
+					{ID: 338},
+					{ID: 11768},
+					{ID: 30619},
+					{ID: 27086},
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTagCollector(&tc.cl)
+
+			err := c.Collect(context.Background(), testutil.DiscardMetrics(t))
+
+			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Error diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTagCollect(t *testing.T) {
+	cl := fakeTagClient{}
+
+	c := newMultiCollectorForTest(t, newTagCollector(&cl))
+
+	testutil.CollectAndCompare(t, c, `
+# HELP paperless_warnings_total Number of warnings generated while scraping metrics.
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
+paperless_warnings_total{category="unspecified"} 0
+`)
+
+	cl.items = append(cl.items, []client.Tag{
+		{ID: 8463, Name: "aaa", Slug: "aslug"},
+		{ID: 338, Name: "three-three-eight", DocumentCount: 13},
+		{ID: 2930, Name: "mybox", Slug: "mb", IsInboxTag: true},
+		{ID: 26429, Name: "last"},
+	}...)
+
+	testutil.CollectAndCompare(t, c, `
+# HELP paperless_tag_document_count Number of documents associated with a tag.
+# TYPE paperless_tag_document_count gauge
+paperless_tag_document_count{id="26429"} 0
+paperless_tag_document_count{id="2930"} 0
+paperless_tag_document_count{id="338"} 13
+paperless_tag_document_count{id="8463"} 0
+# HELP paperless_tag_inbox Whether the tag is marked as an inbox tag.
+# TYPE paperless_tag_inbox gauge
+paperless_tag_inbox{id="26429"} 0
+paperless_tag_inbox{id="2930"} 1
+paperless_tag_inbox{id="338"} 0
+paperless_tag_inbox{id="8463"} 0
+# HELP paperless_tag_info Static information about a tag.
+# TYPE paperless_tag_info gauge
+paperless_tag_info{id="26429",name="last",slug=""} 1
+paperless_tag_info{id="2930",name="mybox",slug="mb"} 1
+paperless_tag_info{id="338",name="three-three-eight",slug=""} 1
+paperless_tag_info{id="8463",name="aaa",slug="aslug"} 1
+# HELP paperless_warnings_total Number of warnings generated while scraping metrics.
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
+paperless_warnings_total{category="unspecified"} 0
+`)
+}
+
+func TestTagCollectCardinalityLimit(t *testing.T) {
+	cl := fakeTagClient{
+		items: []client.Tag{
+			{ID: 1, Name: "one", DocumentCount: 3},
+			{ID: 2, Name: "two", DocumentCount: 20},
+			{ID: 3, Name: "three", DocumentCount: 5, IsInboxTag: true},
+			{ID: 4, Name: "four", DocumentCount: 7},
+			{ID: 5, Name: "five", DocumentCount: 5},
+		},
+	}
+
+	tc := newTagCollector(&cl)
+	tc.setCardinalityLimit(2)
+
+	c := newMultiCollectorForTest(t, tc)
+
+	testutil.CollectAndCompare(t, c, `
+# HELP paperless_tag_document_count Number of documents associated with a tag.
+# TYPE paperless_tag_document_count gauge
+paperless_tag_document_count{id="2"} 20
+paperless_tag_document_count{id="4"} 7
+paperless_tag_document_count{id="other"} 13
+# HELP paperless_tag_folded_objects Number of tags folded into the id="other" aggregate by the cardinality limit.
+# TYPE paperless_tag_folded_objects gauge
+paperless_tag_folded_objects 3
+# HELP paperless_tag_inbox Whether the tag is marked as an inbox tag.
+# TYPE paperless_tag_inbox gauge
+paperless_tag_inbox{id="2"} 0
+paperless_tag_inbox{id="4"} 0
+paperless_tag_inbox{id="other"} 1
+# HELP paperless_tag_info Static information about a tag.
+# TYPE paperless_tag_info gauge
+paperless_tag_info{id="2",name="two",slug=""} 1
+paperless_tag_info{id="4",name="four",slug=""} 1
+paperless_tag_info{id="other",name="",slug=""} 1
+`, "paperless_tag_document_count", "paperless_tag_folded_objects", "paperless_tag_inbox", "paperless_tag_info")
+
+	tc.setCardinalityLimit(10)
+
+	testutil.CollectAndCompare(t, c, `
+# HELP paperless_tag_document_count Number of documents associated with a tag.
+# TYPE paperless_tag_document_count gauge
+paperless_tag_document_count{id="1"} 3
+paperless_tag_document_count{id="2"} 20
+paperless_tag_document_count{id="3"} 5
+paperless_tag_document_count{id="4"} 7
+paperless_tag_document_count{id="5"} 5
+# HELP paperless_tag_folded_objects Number of tags folded into the id="other" aggregate by the cardinality limit.
+# TYPE paperless_tag_folded_objects gauge
+paperless_tag_folded_objects 0
+`, "paperless_tag_document_count", "paperless_tag_folded_objects")
+}
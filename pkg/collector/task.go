@@ -1,7 +1,9 @@
-package main
+package collector
 
 import (
 	"context"
+	"maps"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -25,17 +27,16 @@ type taskClient interface {
 type taskCollector struct {
 	cl taskClient
 
-	infoDesc     *prometheus.Desc
-	createdDesc  *prometheus.Desc
-	doneDesc     *prometheus.Desc
-	statusDesc   *prometheus.Desc
-	filenameDesc *prometheus.Desc
-
-	statusInfoVec *prometheus.GaugeVec
+	infoDesc       *prometheus.Desc
+	createdDesc    *prometheus.Desc
+	doneDesc       *prometheus.Desc
+	statusDesc     *prometheus.Desc
+	filenameDesc   *prometheus.Desc
+	statusInfoDesc *prometheus.Desc
 }
 
 func newTaskCollector(cl taskClient) *taskCollector {
-	c := &taskCollector{
+	return &taskCollector{
 		cl: cl,
 
 		infoDesc: prometheus.NewDesc("paperless_task_info",
@@ -53,32 +54,19 @@ func newTaskCollector(cl taskClient) *taskCollector {
 		filenameDesc: prometheus.NewDesc("paperless_task_filename",
 			"Filename associated with the task (if any).",
 			[]string{"id", "filename"}, nil),
-
-		statusInfoVec: prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Name: "paperless_task_status_info",
-			Help: "Task status names.",
-		}, []string{"status"}),
+		statusInfoDesc: prometheus.NewDesc("paperless_task_status_info",
+			"Task status names.",
+			[]string{"status"}, nil),
 	}
-
-	c.ensureStatusInfo(client.TaskSuccess)
-
-	return c
 }
 
 // Returns a canonicalized status string for labels.
-func (c *taskCollector) ensureStatusInfo(s client.TaskStatus) string {
-	status := strings.ToLower(s.String())
-
-	c.statusInfoVec.With(prometheus.Labels{
-		"status": status,
-	}).Set(1)
-
-	return status
+func taskStatusLabel(s client.TaskStatus) string {
+	return strings.ToLower(s.String())
 }
 
-func (c *taskCollector) describe(ch chan<- *prometheus.Desc) {
-	c.statusInfoVec.Describe(ch)
-
+func (c *taskCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.statusInfoDesc
 	ch <- c.infoDesc
 	ch <- c.createdDesc
 	ch <- c.doneDesc
@@ -86,12 +74,18 @@ func (c *taskCollector) describe(ch chan<- *prometheus.Desc) {
 	ch <- c.filenameDesc
 }
 
-func (c *taskCollector) collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+func (c *taskCollector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	b := newMetricBuilder(ch)
+
 	tasks, _, err := c.cl.ListTasks(ctx)
 	if err != nil {
 		return err
 	}
 
+	statuses := map[string]struct{}{
+		taskStatusLabel(client.TaskSuccess): {},
+	}
+
 	for _, task := range tasks {
 		var filename string
 
@@ -101,26 +95,27 @@ func (c *taskCollector) collect(ctx context.Context, ch chan<- prometheus.Metric
 
 		id := strconv.FormatInt(task.ID, 10)
 
-		ch <- prometheus.MustNewConstMetric(c.infoDesc, prometheus.GaugeValue, 1,
+		b.gauge(c.infoDesc, 1,
 			id,
 			task.TaskID,
 			task.Type,
 		)
 
-		ch <- prometheus.MustNewConstMetric(c.createdDesc, prometheus.GaugeValue,
-			optionalTimestamp(task.Created), id)
+		b.gauge(c.createdDesc, optionalTimestamp(task.Created), id)
+
+		b.gauge(c.doneDesc, optionalTimestamp(task.Done), id)
 
-		ch <- prometheus.MustNewConstMetric(c.doneDesc, prometheus.GaugeValue,
-			optionalTimestamp(task.Done), id)
+		status := taskStatusLabel(task.Status)
+		statuses[status] = struct{}{}
 
-		ch <- prometheus.MustNewConstMetric(c.statusDesc, prometheus.GaugeValue,
-			1, id, c.ensureStatusInfo(task.Status))
+		b.gauge(c.statusDesc, 1, id, status)
 
-		ch <- prometheus.MustNewConstMetric(c.filenameDesc, prometheus.GaugeValue,
-			1, id, filename)
+		b.gauge(c.filenameDesc, 1, id, filename)
 	}
 
-	c.statusInfoVec.Collect(ch)
+	for _, status := range slices.Sorted(maps.Keys(statuses)) {
+		b.gauge(c.statusInfoDesc, 1, status)
+	}
 
 	return nil
 }
@@ -1,4 +1,4 @@
-package main
+package collector
 
 import (
 	"context"
@@ -51,7 +51,7 @@ func TestTask(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			c := newTaskCollector(&tc.cl)
 
-			err := c.collect(context.Background(), testutil.DiscardMetrics(t))
+			err := c.Collect(context.Background(), testutil.DiscardMetrics(t))
 
 			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Errorf("Error diff (-want +got):\n%s", diff)
@@ -70,7 +70,11 @@ func TestTaskCollect(t *testing.T) {
 # TYPE paperless_task_status_info gauge
 paperless_task_status_info{status="success"} 1
 # HELP paperless_warnings_total Number of warnings generated while scraping metrics.
-# TYPE paperless_warnings_total gauge
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
 paperless_warnings_total{category="unspecified"} 0
 `)
 
@@ -100,7 +104,20 @@ paperless_task_status{id="31563",status="statusunspecified"} 1
 paperless_task_status_info{status="statusunspecified"} 1
 paperless_task_status_info{status="success"} 1
 # HELP paperless_warnings_total Number of warnings generated while scraping metrics.
-# TYPE paperless_warnings_total gauge
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
 paperless_warnings_total{category="unspecified"} 0
 `)
+
+	// Statuses of earlier collections aren't retained.
+	cl.tasks = nil
+
+	testutil.CollectAndCompare(t, c, `
+# HELP paperless_task_status_info Task status names.
+# TYPE paperless_task_status_info gauge
+paperless_task_status_info{status="success"} 1
+`, "paperless_task_status_info")
 }
@@ -0,0 +1,56 @@
+package collector
+
+import (
+	"context"
+
+	"github.com/hansmi/paperhooks/pkg/client"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Instrumentation name of the tracers used for spans of the collectors.
+const tracerName = "github.com/hansmi/prometheus-paperless-exporter/pkg/collector"
+
+// tracerFromContext returns a tracer from the provider of the span in the
+// context. Without a span the tracer is a no-op.
+func tracerFromContext(ctx context.Context) trace.Tracer {
+	return trace.SpanFromContext(ctx).TracerProvider().Tracer(tracerName)
+}
+
+// endSpan records the error, if any, and ends the span.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// tracedPageFunc wraps a page listing function to fetch each page within a
+// dedicated span. The spans include the page number and item counts.
+func tracedPageFunc[T any](fetch listPageFunc[T]) listPageFunc[T] {
+	return func(ctx context.Context, page *client.PageOptions) ([]T, *client.Response, error) {
+		number := 1
+
+		if page != nil && page.Number > 0 {
+			number = page.Number
+		}
+
+		ctx, span := tracerFromContext(ctx).Start(ctx, "fetch page",
+			trace.WithAttributes(attribute.Int("paperless.page", number)))
+
+		items, resp, err := fetch(ctx, page)
+
+		span.SetAttributes(attribute.Int("paperless.page.items", len(items)))
+
+		if resp != nil && resp.ItemCount != client.ItemCountUnknown {
+			span.SetAttributes(attribute.Int64("paperless.item_count", resp.ItemCount))
+		}
+
+		endSpan(span, err)
+
+		return items, resp, err
+	}
+}
@@ -0,0 +1,157 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hansmi/paperhooks/pkg/client"
+	"github.com/hansmi/prometheus-paperless-exporter/internal/testutil"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type spanSummary struct {
+	Name   string
+	Parent string
+	Status codes.Code
+	Attrs  map[string]string
+}
+
+func summarizeSpans(spans tracetest.SpanStubs) []spanSummary {
+	names := map[trace.SpanID]string{}
+
+	for _, s := range spans {
+		names[s.SpanContext.SpanID()] = s.Name
+	}
+
+	var result []spanSummary
+
+	for _, s := range spans {
+		summary := spanSummary{
+			Name:   s.Name,
+			Parent: names[s.Parent.SpanID()],
+			Status: s.Status.Code,
+			Attrs:  map[string]string{},
+		}
+
+		for _, kv := range s.Attributes {
+			summary.Attrs[string(kv.Key)] = kv.Value.Emit()
+		}
+
+		result = append(result, summary)
+	}
+
+	return result
+}
+
+func TestCollectorTracing(t *testing.T) {
+	errTest := errors.New("test error")
+
+	for _, tc := range []struct {
+		name string
+		cl   fakeTagClient
+		want []spanSummary
+	}{
+		{
+			name: "success",
+			cl: fakeTagClient{
+				items: []client.Tag{{ID: 1}, {ID: 2}, {ID: 3}},
+			},
+			want: []spanSummary{
+				{
+					Name:   "fetch page",
+					Parent: "collect tag",
+					Attrs: map[string]string{
+						"paperless.page":       "1",
+						"paperless.page.items": "2",
+						"paperless.item_count": "3",
+					},
+				},
+				{
+					Name:   "fetch page",
+					Parent: "collect tag",
+					Attrs: map[string]string{
+						"paperless.page":       "2",
+						"paperless.page.items": "1",
+						"paperless.item_count": "3",
+					},
+				},
+				{
+					Name:   "collect tag",
+					Parent: "scrape",
+					Attrs: map[string]string{
+						"paperless.collector": "tag",
+					},
+				},
+				{
+					Name: "scrape",
+					Attrs: map[string]string{
+						"paperless.collectors": `["tag"]`,
+					},
+				},
+			},
+		},
+		{
+			name: "listing fails",
+			cl: fakeTagClient{
+				err: errTest,
+			},
+			want: []spanSummary{
+				{
+					Name:   "fetch page",
+					Parent: "collect tag",
+					Status: codes.Error,
+					Attrs: map[string]string{
+						"paperless.page":       "1",
+						"paperless.page.items": "0",
+					},
+				},
+				{
+					Name:   "collect tag",
+					Parent: "scrape",
+					Status: codes.Error,
+					Attrs: map[string]string{
+						"paperless.collector": "tag",
+					},
+				},
+				{
+					Name:   "scrape",
+					Status: codes.Error,
+					Attrs: map[string]string{
+						"paperless.collectors": `["tag"]`,
+					},
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			exporter := tracetest.NewInMemoryExporter()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+			c := newMultiCollector(newTagCollector(&tc.cl))
+			c.ids = []string{"tag"}
+			c.logger = slog.New(slog.DiscardHandler)
+			c.tracer = tp.Tracer(tracerName)
+
+			c.Collect(testutil.DiscardMetrics(t))
+
+			if diff := cmp.Diff(tc.want, summarizeSpans(exporter.GetSpans())); diff != "" {
+				t.Errorf("Span diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTracerFromContextWithoutSpan(t *testing.T) {
+	_, span := tracerFromContext(context.Background()).Start(context.Background(), "test")
+	defer span.End()
+
+	if span.IsRecording() {
+		t.Errorf("Span without parent is recording")
+	}
+}
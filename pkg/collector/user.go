@@ -1,4 +1,4 @@
-package main
+package collector
 
 import (
 	"context"
@@ -27,11 +27,13 @@ func newUserCollector(cl userClient) *userCollector {
 	}
 }
 
-func (c *userCollector) describe(ch chan<- *prometheus.Desc) {
+func (c *userCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.countDesc
 }
 
-func (c *userCollector) collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+func (c *userCollector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	b := newMetricBuilder(ch)
+
 	_, response, err := c.cl.ListUsers(ctx, client.ListUsersOptions{})
 
 	if err != nil {
@@ -39,8 +41,7 @@ func (c *userCollector) collect(ctx context.Context, ch chan<- prometheus.Metric
 	}
 
 	if response.ItemCount != client.ItemCountUnknown {
-		ch <- prometheus.MustNewConstMetric(c.countDesc, prometheus.GaugeValue,
-			float64(response.ItemCount))
+		b.gauge(c.countDesc, float64(response.ItemCount))
 	}
 
 	return nil
@@ -0,0 +1,149 @@
+package collector
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// WarningCategory classifies non-fatal errors reported by collectors.
+// Categories are obtained via [RegisterWarningCategory].
+type WarningCategory struct {
+	name string
+}
+
+// String returns the name of the category as used for the "category" label.
+func (c *WarningCategory) String() string {
+	if c == nil {
+		return warningCategoryUnspecified.name
+	}
+
+	return c.name
+}
+
+var (
+	warningCategoriesMu sync.RWMutex
+	warningCategories   = map[string]*WarningCategory{}
+)
+
+var (
+	warningCategoryUnspecified      = MustRegisterWarningCategory("unspecified")
+	warningCategoryGetRemoteVersion = MustRegisterWarningCategory("get_remote_version")
+	warningCategoryCollectorRefresh = MustRegisterWarningCategory("collector_refresh")
+	warningCategoryTimeout          = MustRegisterWarningCategory("timeout")
+	warningCategoryInvalidMetric    = MustRegisterWarningCategory("invalid_metric")
+)
+
+// RegisterWarningCategory makes a warning category available under the given
+// name. Names follow the same rules as collector IDs. Counters for all
+// registered categories are reported from the start, so categories should be
+// registered before any collector is built, usually from an init function.
+func RegisterWarningCategory(name string) (*WarningCategory, error) {
+	if !idPattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid warning category %q", name)
+	}
+
+	warningCategoriesMu.Lock()
+	defer warningCategoriesMu.Unlock()
+
+	if _, ok := warningCategories[name]; ok {
+		return nil, fmt.Errorf("warning category already registered: %s", name)
+	}
+
+	c := &WarningCategory{name: name}
+
+	warningCategories[name] = c
+
+	return c, nil
+}
+
+// MustRegisterWarningCategory is like [RegisterWarningCategory], but panics on
+// errors.
+func MustRegisterWarningCategory(name string) *WarningCategory {
+	c, err := RegisterWarningCategory(name)
+	if err != nil {
+		panic(err)
+	}
+
+	return c
+}
+
+// warningCategoryNames returns the sorted names of all registered categories.
+func warningCategoryNames() []string {
+	warningCategoriesMu.RLock()
+	defer warningCategoriesMu.RUnlock()
+
+	return slices.Sorted(maps.Keys(warningCategories))
+}
+
+// warning is a special form of a metric and suitable for reporting non-fatal
+// errors during a scrape. Warnings are logged, counted per category and
+// retained for inspection.
+type warning struct {
+	category *WarningCategory
+	err      error
+
+	// ID of the collector reporting the warning. Set when the warning is
+	// received from a member.
+	collector string
+
+	// API endpoint involved, if known. Derived from the error otherwise.
+	endpoint string
+}
+
+var _ prometheus.Metric = (*warning)(nil)
+
+func newWarning(category *WarningCategory, err error) *warning {
+	return &warning{category: category, err: err}
+}
+
+// NewWarning returns a pseudo-metric reporting a non-fatal error. Members
+// send it alongside their metrics instead of failing the whole scrape. The
+// error message is logged and shown on the status page without redaction and
+// must not contain label values.
+func NewWarning(category *WarningCategory, err error) prometheus.Metric {
+	return newWarning(category, err)
+}
+
+// withEndpoint returns a copy of the warning for the given API endpoint.
+func (w *warning) withEndpoint(endpoint string) *warning {
+	result := *w
+	result.endpoint = endpoint
+
+	return &result
+}
+
+// withCollector returns a copy of the warning attributed to the given
+// collector unless already attributed.
+func (w *warning) withCollector(id string) *warning {
+	if w.collector != "" {
+		return w
+	}
+
+	result := *w
+	result.collector = id
+
+	return &result
+}
+
+// resolvedEndpoint returns the API endpoint involved, if known.
+func (w *warning) resolvedEndpoint() string {
+	if w.endpoint != "" {
+		return w.endpoint
+	}
+
+	return endpointFromError(w.err)
+}
+
+func (*warning) Desc() *prometheus.Desc {
+	return nil
+}
+
+func (*warning) Write(*dto.Metric) error {
+	return os.ErrInvalid
+}
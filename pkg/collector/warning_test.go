@@ -0,0 +1,150 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hansmi/prometheus-paperless-exporter/internal/testutil"
+)
+
+func registerWarningCategoryForTest(t *testing.T, name string) *WarningCategory {
+	t.Helper()
+
+	c, err := RegisterWarningCategory(name)
+	if err != nil {
+		t.Fatalf("RegisterWarningCategory(%q) failed: %v", name, err)
+	}
+
+	t.Cleanup(func() {
+		warningCategoriesMu.Lock()
+		defer warningCategoriesMu.Unlock()
+
+		delete(warningCategories, name)
+	})
+
+	return c
+}
+
+func TestRegisterWarningCategory(t *testing.T) {
+	registerWarningCategoryForTest(t, "acme_quota")
+
+	for _, tc := range []struct {
+		name    string
+		wantErr error
+	}{
+		{name: "acme_quota", wantErr: cmpopts.AnyError},
+		{name: "timeout", wantErr: cmpopts.AnyError},
+		{name: "", wantErr: cmpopts.AnyError},
+		{name: "Quota-Exceeded", wantErr: cmpopts.AnyError},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := RegisterWarningCategory(tc.name)
+
+			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Error diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if got, want := (*WarningCategory)(nil).String(), "unspecified"; got != want {
+		t.Errorf("String() of nil category = %q, want %q", got, want)
+	}
+}
+
+func TestWarningCounters(t *testing.T) {
+	quota := registerWarningCategoryForTest(t, "acme_quota")
+
+	m := &warningMember{
+		warnings: []*warning{
+			NewWarning(quota, errors.New("quota exceeded")).(*warning),
+		},
+	}
+
+	c := newMultiCollectorForTest(t, m)
+
+	for range 3 {
+		testutil.CollectAndCompare(t, c, "", "paperless_none")
+	}
+
+	testutil.CollectAndCompare(t, c, `
+# HELP paperless_warning_last_timestamp_seconds Number of seconds since 1970 of the last warning in a category.
+# TYPE paperless_warning_last_timestamp_seconds gauge
+paperless_warning_last_timestamp_seconds{category="acme_quota"} 1.5778368e+09
+# HELP paperless_warnings_total Number of warnings generated while scraping metrics.
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="acme_quota"} 4
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
+paperless_warnings_total{category="unspecified"} 0
+`)
+
+	// Filtered collectors share the counters.
+	filtered, err := c.Filter(nil, nil)
+	if err != nil {
+		t.Fatalf("Filter() failed: %v", err)
+	}
+
+	testutil.CollectAndCompare(t, filtered, `
+# HELP paperless_warnings_total Number of warnings generated while scraping metrics.
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="acme_quota"} 5
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} 0
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
+paperless_warnings_total{category="unspecified"} 0
+`, "paperless_warnings_total")
+}
+
+func TestWarningRecorderRecent(t *testing.T) {
+	now := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	r := newWarningRecorder(3)
+	r.now = func() time.Time { return now }
+
+	if diff := cmp.Diff([]WarningRecord{}, r.recentWarnings(), cmpopts.EquateEmpty()); diff != "" {
+		t.Errorf("recentWarnings() diff (-want +got):\n%s", diff)
+	}
+
+	for i := range 5 {
+		now = now.Add(time.Minute)
+
+		r.record(newWarning(warningCategoryInvalidMetric, fmt.Errorf("warning %d", i)).
+			withCollector("tag").
+			withEndpoint("/api/tags/"))
+	}
+
+	want := []WarningRecord{
+		{
+			Time:      time.Date(2020, time.January, 1, 0, 5, 0, 0, time.UTC),
+			Collector: "tag",
+			Category:  "invalid_metric",
+			Endpoint:  "/api/tags/",
+			Message:   "warning 4",
+		},
+		{
+			Time:      time.Date(2020, time.January, 1, 0, 4, 0, 0, time.UTC),
+			Collector: "tag",
+			Category:  "invalid_metric",
+			Endpoint:  "/api/tags/",
+			Message:   "warning 3",
+		},
+		{
+			Time:      time.Date(2020, time.January, 1, 0, 3, 0, 0, time.UTC),
+			Collector: "tag",
+			Category:  "invalid_metric",
+			Endpoint:  "/api/tags/",
+			Message:   "warning 2",
+		},
+	}
+
+	if diff := cmp.Diff(want, r.recentWarnings()); diff != "" {
+		t.Errorf("recentWarnings() diff (-want +got):\n%s", diff)
+	}
+}
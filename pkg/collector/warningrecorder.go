@@ -0,0 +1,124 @@
+package collector
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Number of recent warnings retained for inspection.
+const defaultRecentWarnings = 50
+
+// WarningRecord describes a warning reported during a collection.
+type WarningRecord struct {
+	Time      time.Time `json:"time"`
+	Collector string    `json:"collector"`
+	Category  string    `json:"category"`
+	Endpoint  string    `json:"endpoint,omitempty"`
+	Message   string    `json:"message"`
+}
+
+// warningRecorder keeps cumulative counts and the most recent occurrences of
+// warnings.
+type warningRecorder struct {
+	now   func() time.Time
+	limit int
+
+	countDesc *prometheus.Desc
+	lastDesc  *prometheus.Desc
+
+	mu     sync.Mutex
+	counts map[string]int
+	last   map[string]time.Time
+
+	// Ring buffer of recent warnings; next is the index of the slot to be
+	// overwritten once the buffer is full.
+	recent []WarningRecord
+	next   int
+}
+
+func newWarningRecorder(limit int) *warningRecorder {
+	return &warningRecorder{
+		now:   time.Now,
+		limit: limit,
+		countDesc: prometheus.NewDesc("paperless_warnings_total",
+			"Number of warnings generated while scraping metrics.",
+			[]string{"category"}, nil),
+		lastDesc: prometheus.NewDesc("paperless_warning_last_timestamp_seconds",
+			"Number of seconds since 1970 of the last warning in a category.",
+			[]string{"category"}, nil),
+		counts: map[string]int{},
+		last:   map[string]time.Time{},
+	}
+}
+
+// record counts the warning and retains it as a recent occurrence.
+func (r *warningRecorder) record(w *warning) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	category := w.category.String()
+
+	r.counts[category]++
+	r.last[category] = now
+
+	if r.limit <= 0 {
+		return
+	}
+
+	entry := WarningRecord{
+		Time:      now,
+		Collector: w.collector,
+		Category:  category,
+		Endpoint:  w.resolvedEndpoint(),
+	}
+
+	if w.err != nil {
+		entry.Message = w.err.Error()
+	}
+
+	if len(r.recent) < r.limit {
+		r.recent = append(r.recent, entry)
+		return
+	}
+
+	r.recent[r.next] = entry
+	r.next = (r.next + 1) % r.limit
+}
+
+// recentWarnings returns the retained warnings, most recent first.
+func (r *warningRecorder) recentWarnings() []WarningRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := append(slices.Clone(r.recent[r.next:]), r.recent[:r.next]...)
+
+	slices.Reverse(result)
+
+	return result
+}
+
+func (r *warningRecorder) describe(ch chan<- *prometheus.Desc) {
+	ch <- r.countDesc
+	ch <- r.lastDesc
+}
+
+// collect reports the counters of all registered categories and the time of
+// the last occurrence of every category seen so far.
+func (r *warningRecorder) collect(ch chan<- prometheus.Metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, category := range warningCategoryNames() {
+		ch <- prometheus.MustNewConstMetric(r.countDesc, prometheus.CounterValue,
+			float64(r.counts[category]), category)
+	}
+
+	for category, t := range r.last {
+		ch <- prometheus.MustNewConstMetric(r.lastDesc, prometheus.GaugeValue,
+			float64(t.UnixMilli())/1000, category)
+	}
+}
@@ -0,0 +1,142 @@
+package exporter
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/hansmi/paperhooks/pkg/client"
+	"golang.org/x/time/rate"
+)
+
+// transportWrapper decorates the HTTP transport used for API requests.
+type transportWrapper func(http.RoundTripper) http.RoundTripper
+
+// chainTransports combines multiple wrappers. The first wrapper is the
+// outermost.
+func chainTransports(wrappers ...transportWrapper) transportWrapper {
+	return func(rt http.RoundTripper) http.RoundTripper {
+		for _, wrap := range slices.Backward(wrappers) {
+			rt = wrap(rt)
+		}
+
+		return rt
+	}
+}
+
+// buildClient creates an API client with the given transport wrapper applied
+// to its HTTP client.
+func buildClient(flags client.Flags, wrap transportWrapper) (*client.Client, error) {
+	opts, err := flags.BuildOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	if wrap != nil {
+		hc := &http.Client{}
+
+		if opts.HTTPClient != nil {
+			*hc = *opts.HTTPClient
+		}
+
+		if hc.Transport == nil {
+			hc.Transport = http.DefaultTransport
+		}
+
+		hc.Transport = wrap(hc.Transport)
+
+		opts.HTTPClient = hc
+	}
+
+	return client.New(*opts), nil
+}
+
+// apiLimiter restricts the number of concurrent API requests and their rate
+// across all clients sharing it.
+type apiLimiter struct {
+	// Semaphore for concurrent requests; nil if unlimited.
+	sem chan struct{}
+
+	// Rate limiter; nil if unlimited.
+	rate *rate.Limiter
+}
+
+// newAPILimiter returns a limiter allowing at most maxConcurrency requests in
+// parallel and requestsPerSecond requests per second. Zero disables the
+// respective limit.
+func newAPILimiter(maxConcurrency int, requestsPerSecond float64) *apiLimiter {
+	l := &apiLimiter{}
+
+	if maxConcurrency > 0 {
+		l.sem = make(chan struct{}, maxConcurrency)
+	}
+
+	if requestsPerSecond > 0 {
+		l.rate = rate.NewLimiter(rate.Limit(requestsPerSecond), max(1, int(math.Ceil(requestsPerSecond))))
+	}
+
+	return l
+}
+
+// wrap returns a transport subject to the limits.
+func (l *apiLimiter) wrap(base http.RoundTripper) http.RoundTripper {
+	if l.sem == nil && l.rate == nil {
+		return base
+	}
+
+	return &limitedTransport{limiter: l, base: base}
+}
+
+type limitedTransport struct {
+	limiter *apiLimiter
+	base    http.RoundTripper
+}
+
+var _ http.RoundTripper = (*limitedTransport)(nil)
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	if t.limiter.rate != nil {
+		if err := t.limiter.rate.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if t.limiter.sem == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	select {
+	case t.limiter.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	release := sync.OnceFunc(func() { <-t.limiter.sem })
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	// The request remains active until the body has been consumed.
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+
+	return resp, nil
+}
+
+type releasingBody struct {
+	io.ReadCloser
+
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	defer b.release()
+
+	return b.ReadCloser.Close()
+}
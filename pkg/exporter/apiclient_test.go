@@ -0,0 +1,118 @@
+package exporter
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func doRequest(ctx context.Context, t *testing.T, rt http.RoundTripper, url string) error {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("NewRequest() failed: %v", err)
+	}
+
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		return err
+	}
+
+	io.Copy(io.Discard, resp.Body)
+
+	return resp.Body.Close()
+}
+
+func TestAPILimiterConcurrency(t *testing.T) {
+	const limit = 2
+
+	var active, peak atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := active.Add(1)
+		defer active.Add(-1)
+
+		for {
+			old := peak.Load()
+
+			if n <= old || peak.CompareAndSwap(old, n) {
+				break
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}))
+	t.Cleanup(srv.Close)
+
+	rt := newAPILimiter(limit, 0).wrap(http.DefaultTransport)
+
+	var wg sync.WaitGroup
+
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if err := doRequest(context.Background(), t, rt, srv.URL); err != nil {
+				t.Errorf("Request failed: %v", err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if got := peak.Load(); got > limit {
+		t.Errorf("Peak concurrency %d exceeds limit of %d", got, limit)
+	}
+}
+
+func TestAPILimiterWait(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(srv.Close)
+
+	for _, tc := range []struct {
+		name    string
+		limiter *apiLimiter
+	}{
+		{name: "concurrency", limiter: newAPILimiter(1, 0)},
+		{name: "rate", limiter: newAPILimiter(0, 0.01)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rt := tc.limiter.wrap(http.DefaultTransport)
+
+			req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Keep the first response open to occupy the only slot.
+			resp, err := rt.RoundTrip(req)
+			if err != nil {
+				t.Fatalf("First request failed: %v", err)
+			}
+
+			t.Cleanup(func() { resp.Body.Close() })
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			t.Cleanup(cancel)
+
+			if err := doRequest(ctx, t, rt, srv.URL); err == nil {
+				t.Errorf("Second request succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestAPILimiterUnlimited(t *testing.T) {
+	base := http.DefaultTransport
+
+	if got := newAPILimiter(0, 0).wrap(base); got != base {
+		t.Errorf("wrap() returned %v, want unmodified transport", got)
+	}
+}
@@ -0,0 +1,122 @@
+package exporter
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// normalizeEndpoint replaces numeric path segments with a placeholder to keep
+// the number of distinct endpoint labels bounded.
+func normalizeEndpoint(path string) string {
+	segments := strings.Split(path, "/")
+
+	for idx, s := range segments {
+		if s == "" {
+			continue
+		}
+
+		if _, err := strconv.ParseUint(s, 10, 64); err == nil {
+			segments[idx] = "{id}"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// apiMetrics instruments HTTP transports used for API requests.
+type apiMetrics struct {
+	duration *prometheus.HistogramVec
+	size     *prometheus.HistogramVec
+}
+
+var _ prometheus.Collector = (*apiMetrics)(nil)
+
+func newAPIMetrics() *apiMetrics {
+	labels := []string{"endpoint", "method", "code"}
+
+	return &apiMetrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "paperless_exporter_api_request_duration_seconds",
+			Help:    "Duration of requests to the Paperless API including the transfer of the response body.",
+			Buckets: prometheus.DefBuckets,
+		}, labels),
+		size: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "paperless_exporter_api_response_size_bytes",
+			Help:    "Size of response bodies received from the Paperless API.",
+			Buckets: prometheus.ExponentialBuckets(256, 4, 8),
+		}, labels),
+	}
+}
+
+func (m *apiMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.duration.Describe(ch)
+	m.size.Describe(ch)
+}
+
+func (m *apiMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.duration.Collect(ch)
+	m.size.Collect(ch)
+}
+
+// wrap returns an instrumented transport.
+func (m *apiMetrics) wrap(base http.RoundTripper) http.RoundTripper {
+	return &instrumentedTransport{metrics: m, base: base}
+}
+
+type instrumentedTransport struct {
+	metrics *apiMetrics
+	base    http.RoundTripper
+}
+
+var _ http.RoundTripper = (*instrumentedTransport)(nil)
+
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	endpoint := normalizeEndpoint(req.URL.Path)
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		t.metrics.duration.WithLabelValues(endpoint, req.Method, "error").Observe(time.Since(start).Seconds())
+		return nil, err
+	}
+
+	code := strconv.Itoa(resp.StatusCode)
+
+	body := &countingBody{ReadCloser: resp.Body}
+	body.done = sync.OnceFunc(func() {
+		t.metrics.duration.WithLabelValues(endpoint, req.Method, code).Observe(time.Since(start).Seconds())
+		t.metrics.size.WithLabelValues(endpoint, req.Method, code).Observe(float64(body.count))
+	})
+
+	resp.Body = body
+
+	return resp, nil
+}
+
+// countingBody counts the number of bytes read from a response body and
+// reports completion when the body is closed.
+type countingBody struct {
+	io.ReadCloser
+
+	count int64
+	done  func()
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.count += int64(n)
+
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	defer b.done()
+
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,92 @@
+package exporter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hansmi/prometheus-paperless-exporter/internal/testutil"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestNormalizeEndpoint(t *testing.T) {
+	for _, tc := range []struct {
+		path string
+		want string
+	}{
+		{path: "", want: ""},
+		{path: "/", want: "/"},
+		{path: "/api/tags/", want: "/api/tags/"},
+		{path: "/api/documents/123/", want: "/api/documents/{id}/"},
+		{path: "/api/documents/123/notes/4", want: "/api/documents/{id}/notes/{id}"},
+		{path: "/api/v2/", want: "/api/v2/"},
+		{path: "/api/-1/", want: "/api/-1/"},
+	} {
+		t.Run(tc.path, func(t *testing.T) {
+			if got := normalizeEndpoint(tc.path); got != tc.want {
+				t.Errorf("normalizeEndpoint(%q) = %q, want %q", tc.path, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAPIMetrics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/missing/" {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Write(make([]byte, 1000))
+	}))
+	t.Cleanup(srv.Close)
+
+	m := newAPIMetrics()
+	rt := m.wrap(http.DefaultTransport)
+
+	for _, path := range []string{"/api/documents/1/", "/api/documents/22/?full_perms=true", "/api/missing/"} {
+		if err := doRequest(context.Background(), t, rt, srv.URL+path); err != nil {
+			t.Errorf("Request for %q failed: %v", path, err)
+		}
+	}
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	if err := doRequest(context.Background(), t, rt, closed.URL+"/api/tags/"); err == nil {
+		t.Errorf("Request succeeded, want error")
+	}
+
+	testutil.CollectAndCompare(t, m, `
+# HELP paperless_exporter_api_response_size_bytes Size of response bodies received from the Paperless API.
+# TYPE paperless_exporter_api_response_size_bytes histogram
+paperless_exporter_api_response_size_bytes_bucket{code="200",endpoint="/api/documents/{id}/",method="GET",le="256"} 0
+paperless_exporter_api_response_size_bytes_bucket{code="200",endpoint="/api/documents/{id}/",method="GET",le="1024"} 2
+paperless_exporter_api_response_size_bytes_bucket{code="200",endpoint="/api/documents/{id}/",method="GET",le="4096"} 2
+paperless_exporter_api_response_size_bytes_bucket{code="200",endpoint="/api/documents/{id}/",method="GET",le="16384"} 2
+paperless_exporter_api_response_size_bytes_bucket{code="200",endpoint="/api/documents/{id}/",method="GET",le="65536"} 2
+paperless_exporter_api_response_size_bytes_bucket{code="200",endpoint="/api/documents/{id}/",method="GET",le="262144"} 2
+paperless_exporter_api_response_size_bytes_bucket{code="200",endpoint="/api/documents/{id}/",method="GET",le="1.048576e+06"} 2
+paperless_exporter_api_response_size_bytes_bucket{code="200",endpoint="/api/documents/{id}/",method="GET",le="4.194304e+06"} 2
+paperless_exporter_api_response_size_bytes_bucket{code="200",endpoint="/api/documents/{id}/",method="GET",le="+Inf"} 2
+paperless_exporter_api_response_size_bytes_sum{code="200",endpoint="/api/documents/{id}/",method="GET"} 2000
+paperless_exporter_api_response_size_bytes_count{code="200",endpoint="/api/documents/{id}/",method="GET"} 2
+paperless_exporter_api_response_size_bytes_bucket{code="404",endpoint="/api/missing/",method="GET",le="256"} 1
+paperless_exporter_api_response_size_bytes_bucket{code="404",endpoint="/api/missing/",method="GET",le="1024"} 1
+paperless_exporter_api_response_size_bytes_bucket{code="404",endpoint="/api/missing/",method="GET",le="4096"} 1
+paperless_exporter_api_response_size_bytes_bucket{code="404",endpoint="/api/missing/",method="GET",le="16384"} 1
+paperless_exporter_api_response_size_bytes_bucket{code="404",endpoint="/api/missing/",method="GET",le="65536"} 1
+paperless_exporter_api_response_size_bytes_bucket{code="404",endpoint="/api/missing/",method="GET",le="262144"} 1
+paperless_exporter_api_response_size_bytes_bucket{code="404",endpoint="/api/missing/",method="GET",le="1.048576e+06"} 1
+paperless_exporter_api_response_size_bytes_bucket{code="404",endpoint="/api/missing/",method="GET",le="4.194304e+06"} 1
+paperless_exporter_api_response_size_bytes_bucket{code="404",endpoint="/api/missing/",method="GET",le="+Inf"} 1
+paperless_exporter_api_response_size_bytes_sum{code="404",endpoint="/api/missing/",method="GET"} 19
+paperless_exporter_api_response_size_bytes_count{code="404",endpoint="/api/missing/",method="GET"} 1
+`, "paperless_exporter_api_response_size_bytes")
+
+	// Failed requests are recorded with an "error" code.
+	if got := promtestutil.CollectAndCount(m.duration); got != 3 {
+		t.Errorf("Got %d duration series, want 3", got)
+	}
+}
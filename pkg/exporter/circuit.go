@@ -0,0 +1,143 @@
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var errCircuitOpen = errors.New("circuit breaker open")
+
+type circuitState struct {
+	// Number of consecutive failures.
+	failures int
+
+	openUntil time.Time
+}
+
+// circuitBreaker stops sending API requests to a host for a cooldown period
+// after repeated consecutive failures. Once the cooldown expires requests are
+// let through again; the first failure re-opens the circuit while the first
+// success closes it.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	openDesc *prometheus.Desc
+
+	mu    sync.Mutex
+	hosts map[string]*circuitState
+}
+
+var _ prometheus.Collector = (*circuitBreaker)(nil)
+
+// newCircuitBreaker returns a breaker opening after the given number of
+// consecutive failures. A threshold of zero disables the breaker.
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+		openDesc: prometheus.NewDesc("paperless_exporter_circuit_open",
+			"Whether requests to a Paperless host are suspended after repeated failures.",
+			[]string{"host"}, nil),
+		hosts: map[string]*circuitState{},
+	}
+}
+
+func (b *circuitBreaker) Describe(ch chan<- *prometheus.Desc) {
+	ch <- b.openDesc
+}
+
+func (b *circuitBreaker) Collect(ch chan<- prometheus.Metric) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+
+	for host, s := range b.hosts {
+		var value float64
+
+		if now.Before(s.openUntil) {
+			value = 1
+		}
+
+		ch <- prometheus.MustNewConstMetric(b.openDesc, prometheus.GaugeValue, value, host)
+	}
+}
+
+// allow reports whether requests to the host may be sent.
+func (b *circuitBreaker) allow(host string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := b.hosts[host]
+
+	return s == nil || !b.now().Before(s.openUntil)
+}
+
+// record updates the state of a host after a request.
+func (b *circuitBreaker) record(host string, success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := b.hosts[host]
+
+	if s == nil {
+		s = &circuitState{}
+		b.hosts[host] = s
+	}
+
+	if success {
+		s.failures = 0
+		s.openUntil = time.Time{}
+		return
+	}
+
+	s.failures++
+
+	if s.failures >= b.threshold {
+		s.openUntil = b.now().Add(b.cooldown)
+	}
+}
+
+// wrap returns a transport subject to the breaker.
+func (b *circuitBreaker) wrap(base http.RoundTripper) http.RoundTripper {
+	if b.threshold < 1 {
+		return base
+	}
+
+	return &circuitTransport{breaker: b, base: base}
+}
+
+type circuitTransport struct {
+	breaker *circuitBreaker
+	base    http.RoundTripper
+}
+
+var _ http.RoundTripper = (*circuitTransport)(nil)
+
+func (t *circuitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+
+	if !t.breaker.allow(host) {
+		return nil, fmt.Errorf("%s: %w", host, errCircuitOpen)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+
+	if err != nil {
+		if req.Context().Err() == nil {
+			t.breaker.record(host, false)
+		}
+	} else {
+		t.breaker.record(host, resp.StatusCode < http.StatusInternalServerError)
+	}
+
+	return resp, err
+}
@@ -0,0 +1,115 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hansmi/prometheus-paperless-exporter/internal/testutil"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	b := newCircuitBreaker(2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	var calls int
+	var result fakeResult
+
+	rt := b.wrap(roundTripFunc(func(*http.Request) (*http.Response, error) {
+		calls++
+		return result.response()
+	}))
+
+	request := func(host string) error {
+		t.Helper()
+
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://"+host+"/api/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = rt.RoundTrip(req)
+
+		return err
+	}
+
+	wantOpen := func(value string) string {
+		return `
+# HELP paperless_exporter_circuit_open Whether requests to a Paperless host are suspended after repeated failures.
+# TYPE paperless_exporter_circuit_open gauge
+paperless_exporter_circuit_open{host="paperless"} ` + value + "\n"
+	}
+
+	result = fakeResult{code: http.StatusOK}
+
+	if err := request("paperless"); err != nil {
+		t.Errorf("Request failed: %v", err)
+	}
+
+	testutil.CollectAndCompare(t, b, wantOpen("0"))
+
+	// Consecutive failures open the circuit.
+	result = fakeResult{code: http.StatusBadGateway}
+	request("paperless")
+
+	result = fakeResult{err: syscall.ECONNREFUSED}
+	request("paperless")
+
+	testutil.CollectAndCompare(t, b, wantOpen("1"))
+
+	calls = 0
+	result = fakeResult{code: http.StatusOK}
+
+	if diff := cmp.Diff(errCircuitOpen, request("paperless"), cmpopts.EquateErrors()); diff != "" {
+		t.Errorf("Request error diff (-want +got):\n%s", diff)
+	}
+
+	if calls != 0 {
+		t.Errorf("Request sent while circuit is open")
+	}
+
+	// Other hosts are unaffected.
+	if err := request("other"); err != nil {
+		t.Errorf("Request failed: %v", err)
+	}
+
+	// A failure after the cooldown re-opens the circuit.
+	now = now.Add(time.Minute)
+
+	testutil.CollectAndCompare(t, b, wantOpen("0")+`paperless_exporter_circuit_open{host="other"} 0
+`)
+
+	result = fakeResult{code: http.StatusServiceUnavailable}
+	request("paperless")
+
+	if err := request("paperless"); !errors.Is(err, errCircuitOpen) {
+		t.Errorf("Request error %v, want %v", err, errCircuitOpen)
+	}
+
+	// A success closes the circuit.
+	now = now.Add(time.Minute)
+	result = fakeResult{code: http.StatusNotFound}
+
+	for range 3 {
+		if err := request("paperless"); err != nil {
+			t.Errorf("Request failed: %v", err)
+		}
+	}
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	base := roundTripFunc(func(*http.Request) (*http.Response, error) { return nil, nil })
+
+	if got := newCircuitBreaker(0, time.Minute).wrap(base); got == nil {
+		t.Errorf("wrap() returned nil")
+	} else if _, ok := got.(roundTripFunc); !ok {
+		t.Errorf("wrap() returned %T, want unmodified transport", got)
+	}
+}
@@ -0,0 +1,127 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/hansmi/paperhooks/pkg/client"
+	"github.com/hansmi/prometheus-paperless-exporter/pkg/collector"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// Formats supported by the collect command.
+var collectFormats = map[string]expfmt.FormatType{
+	"text":        expfmt.TypeTextPlain,
+	"openmetrics": expfmt.TypeOpenMetrics,
+}
+
+// collectOptions configures a one-shot collection.
+type collectOptions struct {
+	// Destination file. Standard output is used if empty or "-".
+	output string
+
+	// Name of the exposition format, see collectFormats.
+	format string
+}
+
+// encodeMetrics writes the metric families in the given format.
+func encodeMetrics(w io.Writer, families []*dto.MetricFamily, format expfmt.Format) error {
+	enc := expfmt.NewEncoder(w, format)
+
+	for _, mf := range families {
+		if err := enc.Encode(mf); err != nil {
+			return err
+		}
+	}
+
+	if closer, ok := enc.(expfmt.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+// writeFileAtomic replaces the file at the given path with the content
+// produced by the write function. Readers see either the previous or the
+// complete new content.
+func writeFileAtomic(path string, write func(io.Writer) error) (err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if err := tmp.Chmod(0o644); err != nil {
+		return err
+	}
+
+	if err := write(tmp); err != nil {
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// collectOnce gathers the metrics of the collector and writes them to the
+// configured destination. Nothing is written if gathering fails.
+func collectOnce(ctx context.Context, c *collector.Collector, opts collectOptions, stdout io.Writer) error {
+	formatType, ok := collectFormats[opts.format]
+	if !ok {
+		return fmt.Errorf("unsupported format %q", opts.format)
+	}
+
+	g, err := c.Gatherer(ctx)
+	if err != nil {
+		return err
+	}
+
+	families, err := g.Gather()
+	if err != nil {
+		return fmt.Errorf("collecting metrics: %w", err)
+	}
+
+	write := func(w io.Writer) error {
+		return encodeMetrics(w, families, expfmt.NewFormat(formatType))
+	}
+
+	if opts.output == "" || opts.output == "-" {
+		return write(stdout)
+	}
+
+	return writeFileAtomic(opts.output, write)
+}
+
+// runCollect builds the collector of the default instance and collects its
+// metrics once.
+func runCollect(cfg *config, opts collector.Options, wrap transportWrapper, clientFlags client.Flags, collectOpts collectOptions) error {
+	// Collect directly instead of waiting for background refreshes.
+	opts.BackgroundInterval = 0
+	cfg.BackgroundInterval = 0
+
+	s, err := newExporterState(cfg, opts, wrap, clientFlags, true, nil)
+	if err != nil {
+		return err
+	}
+
+	defer s.cancel()
+
+	return collectOnce(context.Background(), s.collector, collectOpts, os.Stdout)
+}
@@ -0,0 +1,196 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hansmi/paperhooks/pkg/client"
+	"github.com/hansmi/prometheus-paperless-exporter/pkg/collector"
+	"github.com/prometheus/common/model"
+)
+
+func newCollectTestCollector(t *testing.T, ids ...string) *collector.Collector {
+	t.Helper()
+
+	c, err := collector.New(newFakePaperless(t, map[string]string{
+		"/api/groups/": `{"count": 3, "results": []}`,
+	}), collector.Options{
+		EnabledIDs: ids,
+		Logger:     slog.New(slog.DiscardHandler),
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	return c
+}
+
+func TestCollectOnce(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		format     string
+		wantPrefix string
+		wantSuffix string
+	}{
+		{
+			name:       "text",
+			format:     "text",
+			wantPrefix: "# HELP paperless_groups Number of user groups.\n# TYPE paperless_groups gauge\npaperless_groups 3\n",
+		},
+		{
+			name:       "openmetrics",
+			format:     "openmetrics",
+			wantPrefix: "# HELP paperless_groups Number of user groups.\n# TYPE paperless_groups gauge\npaperless_groups 3.0\n",
+			wantSuffix: "# EOF\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			if err := collectOnce(context.Background(), newCollectTestCollector(t, "group"), collectOptions{
+				format: tc.format,
+			}, &buf); err != nil {
+				t.Fatalf("collectOnce() failed: %v", err)
+			}
+
+			if got := buf.String(); !strings.HasPrefix(got, tc.wantPrefix) || !strings.HasSuffix(got, tc.wantSuffix) {
+				t.Errorf("Output %q lacks prefix %q or suffix %q", got, tc.wantPrefix, tc.wantSuffix)
+			}
+		})
+	}
+}
+
+func TestCollectOnceFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "paperless.prom")
+
+	if err := os.WriteFile(path, []byte("previous\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Failed collections leave the file untouched.
+	err := collectOnce(context.Background(), newCollectTestCollector(t, "group", "user"), collectOptions{
+		output: path,
+		format: "text",
+	}, io.Discard)
+
+	if diff := cmp.Diff(cmpopts.AnyError, err, cmpopts.EquateErrors()); diff != "" {
+		t.Errorf("collectOnce() error diff (-want +got):\n%s", diff)
+	}
+
+	if content, err := os.ReadFile(path); err != nil {
+		t.Errorf("ReadFile() failed: %v", err)
+	} else if diff := cmp.Diff("previous\n", string(content)); diff != "" {
+		t.Errorf("File content diff (-want +got):\n%s", diff)
+	}
+
+	if err := collectOnce(context.Background(), newCollectTestCollector(t, "group"), collectOptions{
+		output: path,
+		format: "text",
+	}, io.Discard); err != nil {
+		t.Fatalf("collectOnce() failed: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() failed: %v", err)
+	}
+
+	if want := "paperless_groups 3\n"; !strings.Contains(string(content), want) {
+		t.Errorf("File content %q does not contain %q", content, want)
+	}
+
+	if fi, err := os.Stat(path); err != nil {
+		t.Errorf("Stat() failed: %v", err)
+	} else if got := fi.Mode().Perm(); got != 0o644 {
+		t.Errorf("File mode is %v, want %v", got, os.FileMode(0o644))
+	}
+
+	// No temporary files remain.
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir() failed: %v", err)
+	}
+
+	if len(entries) != 1 {
+		t.Errorf("Directory contains %d entries, want 1", len(entries))
+	}
+}
+
+func TestCollectOnceUnsupportedFormat(t *testing.T) {
+	err := collectOnce(context.Background(), newCollectTestCollector(t, "group"), collectOptions{
+		format: "json",
+	}, io.Discard)
+
+	if diff := cmp.Diff(cmpopts.AnyError, err, cmpopts.EquateErrors()); diff != "" {
+		t.Errorf("collectOnce() error diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestWriteFileAtomicFailure(t *testing.T) {
+	errTest := errors.New("test error")
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.prom")
+
+	err := writeFileAtomic(path, func(w io.Writer) error {
+		io.WriteString(w, "partial")
+		return errTest
+	})
+
+	if diff := cmp.Diff(errTest, err, cmpopts.EquateErrors()); diff != "" {
+		t.Errorf("writeFileAtomic() error diff (-want +got):\n%s", diff)
+	}
+
+	if entries, err := os.ReadDir(dir); err != nil {
+		t.Errorf("ReadDir() failed: %v", err)
+	} else if len(entries) != 0 {
+		t.Errorf("Directory contains %d entries, want none", len(entries))
+	}
+}
+
+func TestRunCollect(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"count": 5, "results": []}`)
+	}))
+	t.Cleanup(ts.Close)
+
+	path := filepath.Join(t.TempDir(), "paperless.prom")
+
+	// Background collection configured in the file is ignored.
+	cfg := &config{
+		BackgroundInterval: model.Duration(time.Hour),
+	}
+
+	if err := runCollect(cfg, collector.Options{
+		EnabledIDs: []string{"group"},
+		Logger:     slog.New(slog.DiscardHandler),
+	}, nil, client.Flags{BaseURL: ts.URL}, collectOptions{
+		output: path,
+		format: "text",
+	}); err != nil {
+		t.Fatalf("runCollect() failed: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() failed: %v", err)
+	}
+
+	if want := "paperless_groups 5\n"; !strings.Contains(string(content), want) {
+		t.Errorf("File content %q does not contain %q", content, want)
+	}
+}
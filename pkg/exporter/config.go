@@ -0,0 +1,245 @@
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"time"
+
+	"github.com/hansmi/paperhooks/pkg/client"
+	"github.com/hansmi/prometheus-paperless-exporter/pkg/collector"
+	"github.com/hansmi/prometheus-paperless-exporter/pkg/relabel"
+	"github.com/prometheus/common/model"
+	"go.yaml.in/yaml/v2"
+)
+
+// targetConfig describes how to connect to a Paperless instance.
+type targetConfig struct {
+	URL              string `yaml:"url"`
+	AuthToken        string `yaml:"auth_token"`
+	AuthTokenFile    string `yaml:"auth_token_file"`
+	AuthUsername     string `yaml:"auth_username"`
+	AuthPassword     string `yaml:"auth_password"`
+	AuthPasswordFile string `yaml:"auth_password_file"`
+	ServerTimezone   string `yaml:"server_timezone"`
+}
+
+func (t targetConfig) validate() error {
+	if t.URL == "" {
+		return errors.New("missing URL")
+	}
+
+	return nil
+}
+
+func (t targetConfig) clientFlags() client.Flags {
+	return client.Flags{
+		BaseURL:          t.URL,
+		AuthToken:        t.AuthToken,
+		AuthTokenFile:    t.AuthTokenFile,
+		AuthUsername:     t.AuthUsername,
+		AuthPassword:     t.AuthPassword,
+		AuthPasswordFile: t.AuthPasswordFile,
+		ServerTimezone:   t.ServerTimezone,
+	}
+}
+
+func (t targetConfig) buildClient(wrap transportWrapper) (*client.Client, error) {
+	return buildClient(t.clientFlags(), wrap)
+}
+
+// memberConfig contains per-collector settings.
+type memberConfig struct {
+	// Enable or disable the collector regardless of the default.
+	Enabled *bool `yaml:"enabled"`
+
+	RefreshInterval model.Duration `yaml:"refresh_interval"`
+	Timeout         model.Duration `yaml:"timeout"`
+
+	// Redaction policies keyed by label name.
+	Redact map[string]collector.RedactionConfig `yaml:"redact"`
+
+	// Maximum number of objects reported individually by collectors emitting
+	// series per object.
+	CardinalityLimit int `yaml:"cardinality_limit"`
+}
+
+type config struct {
+	// Default Paperless instance served at the metrics path. Overrides the
+	// client flags.
+	Paperless *targetConfig `yaml:"paperless"`
+
+	// Enabled collector IDs. All default collectors are enabled if empty.
+	Collectors []string `yaml:"collectors"`
+
+	// Per-collector settings keyed by collector ID.
+	CollectorOptions map[string]memberConfig `yaml:"collector_options"`
+
+	ScrapeTimeout       model.Duration `yaml:"scrape_timeout"`
+	ScrapeTimeoutOffset model.Duration `yaml:"scrape_timeout_offset"`
+	BackgroundInterval  model.Duration `yaml:"background_interval"`
+
+	// Shorthand for enabling the remote_version collector.
+	EnableRemoteNetwork *bool `yaml:"enable_remote_network"`
+
+	// Paperless instances for the probe endpoint keyed by target name.
+	Targets map[string]targetConfig `yaml:"targets"`
+
+	// Relabeling rules applied before exposition.
+	MetricRelabelConfigs []*relabel.Config `yaml:"metric_relabel_configs"`
+
+	// Validated redaction policies keyed by collector ID and label name.
+	redactPolicies map[string]map[string]*collector.RedactionPolicy
+}
+
+func (c *config) validate() error {
+	if c.Paperless != nil {
+		if err := c.Paperless.validate(); err != nil {
+			return fmt.Errorf("paperless: %w", err)
+		}
+	}
+
+	if err := collector.CheckIDs(c.Collectors); err != nil {
+		return err
+	}
+
+	c.redactPolicies = map[string]map[string]*collector.RedactionPolicy{}
+
+	for id, mc := range c.CollectorOptions {
+		if _, ok := collector.Lookup(id); !ok {
+			return fmt.Errorf("options for unknown collector: %s", id)
+		}
+
+		if mc.CardinalityLimit < 0 {
+			return fmt.Errorf("collector %s: cardinality limit must not be negative", id)
+		}
+
+		if mc.CardinalityLimit > 0 && !collector.SupportsCardinalityLimit(id) {
+			return fmt.Errorf("collector %s does not support a cardinality limit", id)
+		}
+
+		for label, rc := range mc.Redact {
+			p, err := rc.Build()
+			if err != nil {
+				return fmt.Errorf("collector %s: redaction of label %q: %w", id, label, err)
+			}
+
+			if c.redactPolicies[id] == nil {
+				c.redactPolicies[id] = map[string]*collector.RedactionPolicy{}
+			}
+
+			c.redactPolicies[id][label] = p
+		}
+	}
+
+	for name, t := range c.Targets {
+		if name == "" {
+			return errors.New("target name must not be empty")
+		}
+
+		if err := t.validate(); err != nil {
+			return fmt.Errorf("target %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// apply overrides collector options with the values set in the configuration.
+func (c *config) apply(opts *collector.Options) {
+	overrides := maps.Clone(opts.Overrides)
+
+	if len(c.Collectors) > 0 {
+		// An explicit list takes precedence over selections via flags.
+		opts.EnabledIDs = c.Collectors
+		overrides = nil
+	}
+
+	if overrides == nil {
+		overrides = map[string]bool{}
+	}
+
+	if c.ScrapeTimeout != 0 {
+		opts.Timeout = time.Duration(c.ScrapeTimeout)
+	}
+
+	if c.ScrapeTimeoutOffset != 0 {
+		opts.TimeoutOffset = time.Duration(c.ScrapeTimeoutOffset)
+	}
+
+	if c.BackgroundInterval != 0 {
+		opts.BackgroundInterval = time.Duration(c.BackgroundInterval)
+	}
+
+	if c.EnableRemoteNetwork != nil {
+		overrides["remote_version"] = *c.EnableRemoteNetwork
+	}
+
+	members := maps.Clone(opts.Members)
+
+	if members == nil {
+		members = map[string]collector.MemberOptions{}
+	}
+
+	for id, mc := range c.CollectorOptions {
+		mo := members[id]
+
+		if mc.Enabled != nil {
+			overrides[id] = *mc.Enabled
+		}
+
+		if mc.RefreshInterval != 0 {
+			mo.RefreshInterval = time.Duration(mc.RefreshInterval)
+		}
+
+		if mc.Timeout != 0 {
+			mo.Timeout = time.Duration(mc.Timeout)
+		}
+
+		if mc.CardinalityLimit != 0 {
+			mo.CardinalityLimit = mc.CardinalityLimit
+		}
+
+		if policies := c.redactPolicies[id]; len(policies) > 0 {
+			mo.Redact = policies
+		}
+
+		members[id] = mo
+	}
+
+	if len(c.MetricRelabelConfigs) > 0 {
+		opts.RelabelConfigs = c.MetricRelabelConfigs
+	}
+
+	opts.Overrides = overrides
+	opts.Members = members
+}
+
+func parseConfig(data []byte) (*config, error) {
+	var c config
+
+	if err := yaml.UnmarshalStrict(data, &c); err != nil {
+		return nil, err
+	}
+
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func loadConfigFile(path string) (*config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := parseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,320 @@
+package exporter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hansmi/prometheus-paperless-exporter/internal/ref"
+	"github.com/hansmi/prometheus-paperless-exporter/pkg/collector"
+	"github.com/hansmi/prometheus-paperless-exporter/pkg/relabel"
+	"github.com/prometheus/common/model"
+)
+
+func TestParseConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		input   string
+		want    *config
+		wantErr error
+	}{
+		{
+			name: "empty",
+			want: &config{},
+		},
+		{
+			name: "targets",
+			input: `
+targets:
+  alice:
+    url: https://paperless.example.com
+    auth_token_file: /etc/exporter/alice-token.txt
+  bob:
+    url: http://localhost:8000
+    auth_username: bob
+    auth_password: secret
+    server_timezone: Europe/Zurich
+`,
+			want: &config{
+				Targets: map[string]targetConfig{
+					"alice": {
+						URL:           "https://paperless.example.com",
+						AuthTokenFile: "/etc/exporter/alice-token.txt",
+					},
+					"bob": {
+						URL:            "http://localhost:8000",
+						AuthUsername:   "bob",
+						AuthPassword:   "secret",
+						ServerTimezone: "Europe/Zurich",
+					},
+				},
+			},
+		},
+		{
+			name: "settings",
+			input: `
+paperless:
+  url: http://localhost:8000
+  auth_token: secret
+collectors: [tag, status]
+collector_options:
+  status:
+    refresh_interval: 30s
+    timeout: 10s
+scrape_timeout: 2m
+background_interval: 5m
+enable_remote_network: true
+`,
+			want: &config{
+				Paperless: &targetConfig{
+					URL:       "http://localhost:8000",
+					AuthToken: "secret",
+				},
+				Collectors: []string{"tag", "status"},
+				CollectorOptions: map[string]memberConfig{
+					"status": {
+						RefreshInterval: model.Duration(30 * time.Second),
+						Timeout:         model.Duration(10 * time.Second),
+					},
+				},
+				ScrapeTimeout:       model.Duration(2 * time.Minute),
+				BackgroundInterval:  model.Duration(5 * time.Minute),
+				EnableRemoteNetwork: ref.Ref(true),
+			},
+		},
+		{
+			name: "redaction",
+			input: `
+collector_options:
+  correspondent:
+    redact:
+      name:
+        action: hash
+        salt: pepper
+  task:
+    redact:
+      filename: {action: truncate, length: 8}
+`,
+			want: &config{
+				CollectorOptions: map[string]memberConfig{
+					"correspondent": {
+						Redact: map[string]collector.RedactionConfig{
+							"name": {Action: "hash", Salt: "pepper"},
+						},
+					},
+					"task": {
+						Redact: map[string]collector.RedactionConfig{
+							"filename": {Action: "truncate", Length: 8},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "invalid redaction",
+			input: `
+collector_options:
+  tag:
+    redact:
+      name: {action: allow, allow: "("}
+`,
+			wantErr: cmpopts.AnyError,
+		},
+		{
+			name: "metric relabeling",
+			input: `
+metric_relabel_configs:
+  - source_labels: [__name__]
+    regex: paperless_tag_.*
+    action: drop
+  - source_labels: [name]
+    target_label: label
+`,
+			want: &config{
+				MetricRelabelConfigs: []*relabel.Config{
+					{
+						SourceLabels: []string{"__name__"},
+						Separator:    ";",
+						Regex:        relabel.MustNewRegexp("paperless_tag_.*"),
+						Replacement:  "$1",
+						Action:       relabel.Drop,
+					},
+					{
+						SourceLabels: []string{"name"},
+						Separator:    ";",
+						Regex:        relabel.MustNewRegexp("(.*)"),
+						TargetLabel:  "label",
+						Replacement:  "$1",
+						Action:       relabel.Replace,
+					},
+				},
+			},
+		},
+		{
+			name: "invalid relabel action",
+			input: `
+metric_relabel_configs:
+  - action: encrypt
+`,
+			wantErr: cmpopts.AnyError,
+		},
+		{
+			name: "cardinality limit",
+			input: `
+collector_options:
+  correspondent:
+    cardinality_limit: 100
+`,
+			want: &config{
+				CollectorOptions: map[string]memberConfig{
+					"correspondent": {CardinalityLimit: 100},
+				},
+			},
+		},
+		{
+			name: "negative cardinality limit",
+			input: `
+collector_options:
+  tag:
+    cardinality_limit: -1
+`,
+			wantErr: cmpopts.AnyError,
+		},
+		{
+			name: "cardinality limit unsupported",
+			input: `
+collector_options:
+  status:
+    cardinality_limit: 10
+`,
+			wantErr: cmpopts.AnyError,
+		},
+		{
+			name:    "unknown collector",
+			input:   `collectors: [tag, unknown]`,
+			wantErr: cmpopts.AnyError,
+		},
+		{
+			name: "options for unknown collector",
+			input: `
+collector_options:
+  unknown:
+    timeout: 1s
+`,
+			wantErr: cmpopts.AnyError,
+		},
+		{
+			name: "default instance without url",
+			input: `
+paperless:
+  auth_token: secret
+`,
+			wantErr: cmpopts.AnyError,
+		},
+		{
+			name: "unknown field",
+			input: `
+targets:
+  alice:
+    url: https://paperless.example.com
+    token: secret
+`,
+			wantErr: cmpopts.AnyError,
+		},
+		{
+			name: "missing url",
+			input: `
+targets:
+  alice: {}
+`,
+			wantErr: cmpopts.AnyError,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseConfig([]byte(tc.input))
+
+			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Error diff (-want +got):\n%s", diff)
+			}
+
+			if err == nil {
+				if diff := cmp.Diff(tc.want, got, cmpopts.IgnoreUnexported(config{}), cmp.Comparer(func(a, b relabel.Regexp) bool {
+					return a.String() == b.String()
+				})); diff != "" {
+					t.Errorf("Config diff (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigApply(t *testing.T) {
+	opts := collector.Options{
+		Timeout:    time.Minute,
+		EnabledIDs: []string{"tag"},
+		Overrides: map[string]bool{
+			"user": true,
+		},
+		Members: map[string]collector.MemberOptions{
+			"tag":    {Timeout: time.Second},
+			"status": {RefreshInterval: time.Hour},
+		},
+	}
+
+	cfg := config{
+		Collectors: []string{"status", "log"},
+		CollectorOptions: map[string]memberConfig{
+			"status": {Timeout: model.Duration(3 * time.Second)},
+			"log":    {RefreshInterval: model.Duration(5 * time.Minute)},
+			"task":   {Enabled: ref.Ref(true)},
+			"tag":    {CardinalityLimit: 50},
+		},
+		ScrapeTimeout:       model.Duration(30 * time.Second),
+		EnableRemoteNetwork: ref.Ref(true),
+	}
+
+	cfg.apply(&opts)
+
+	want := collector.Options{
+		Timeout:    30 * time.Second,
+		EnabledIDs: []string{"status", "log"},
+		Overrides: map[string]bool{
+			"remote_version": true,
+			"task":           true,
+		},
+		Members: map[string]collector.MemberOptions{
+			"tag":    {Timeout: time.Second, CardinalityLimit: 50},
+			"status": {RefreshInterval: time.Hour, Timeout: 3 * time.Second},
+			"log":    {RefreshInterval: 5 * time.Minute},
+			"task":   {},
+		},
+	}
+
+	if diff := cmp.Diff(want, opts); diff != "" {
+		t.Errorf("Options diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+
+	if _, err := loadConfigFile(path); err == nil {
+		t.Errorf("loadConfigFile() succeeded for missing file")
+	}
+
+	if err := os.WriteFile(path, []byte("targets:\n  x:\n    url: http://localhost\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := loadConfigFile(path)
+	if err != nil {
+		t.Errorf("loadConfigFile() failed: %v", err)
+	}
+
+	if diff := cmp.Diff(map[string]targetConfig{"x": {URL: "http://localhost"}}, got.Targets); diff != "" {
+		t.Errorf("Targets diff (-want +got):\n%s", diff)
+	}
+}
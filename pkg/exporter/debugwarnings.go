@@ -0,0 +1,63 @@
+package exporter
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/hansmi/prometheus-paperless-exporter/pkg/collector"
+)
+
+// warningTableTemplate defines the "warningTable" template rendering a list
+// of warning records. It's shared by all pages showing warnings.
+const warningTableTemplate = `{{define "warningTable"}}
+{{- if .}}
+<table>
+<tr><th>Time</th><th>Collector</th><th>Category</th><th>Endpoint</th><th>Message</th></tr>
+{{- range .}}
+<tr><td>{{.Time.Format "2006-01-02T15:04:05Z07:00"}}</td><td>{{.Collector}}</td><td>{{.Category}}</td><td>{{.Endpoint}}</td><td>{{.Message}}</td></tr>
+{{- end}}
+</table>
+{{- else}}
+<p>No warnings.</p>
+{{- end}}
+{{- end}}`
+
+// newPageTemplate parses an HTML page which may use the shared templates.
+func newPageTemplate(name, text string) *template.Template {
+	return template.Must(template.Must(template.New(name).Parse(text)).Parse(warningTableTemplate))
+}
+
+var warningsTemplate = newPageTemplate("warnings", `<html>
+<head><title>Paperless Exporter Warnings</title></head>
+<body>
+<h1>Recent warnings</h1>
+{{- range .}}
+<h2>{{.Name}}</h2>
+{{- template "warningTable" .Warnings}}
+{{- end}}
+</body>
+</html>
+`)
+
+// serveWarnings lists the recent warnings of all instances in the current
+// state.
+func (r *reloader) serveWarnings(w http.ResponseWriter, req *http.Request) {
+	type instance struct {
+		Name     string
+		Warnings []collector.WarningRecord
+	}
+
+	var instances []instance
+
+	if s := r.state.Load(); s != nil {
+		for _, i := range s.instances {
+			instances = append(instances, instance{i.name, i.collector.RecentWarnings()})
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	if err := warningsTemplate.Execute(w, instances); err != nil {
+		r.logger.Error("Rendering warnings failed", "err", err)
+	}
+}
@@ -0,0 +1,70 @@
+package exporter
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hansmi/prometheus-paperless-exporter/pkg/collector"
+)
+
+func TestServeWarnings(t *testing.T) {
+	c, err := collector.New(newFakePaperless(t, nil), collector.Options{
+		EnabledIDs: []string{"remote_version"},
+		Logger:     slog.New(slog.DiscardHandler),
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	quiet, err := collector.New(newFakePaperless(t, nil), collector.Options{
+		EnabledIDs: []string{"group"},
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	r := newReloader(slog.New(slog.DiscardHandler), func(*exporterState) (*exporterState, error) {
+		return &exporterState{
+			collector: c,
+			cancel:    func() {},
+			instances: []instance{
+				{name: defaultInstanceName, collector: c},
+				{name: "quiet<>", collector: quiet},
+			},
+		}, nil
+	})
+
+	if err := r.reload(); err != nil {
+		t.Fatalf("reload() failed: %v", err)
+	}
+
+	g, err := c.Gatherer(context.Background())
+	if err != nil {
+		t.Fatalf("Gatherer() failed: %v", err)
+	}
+
+	if _, err := g.Gather(); err != nil {
+		t.Errorf("Gather() failed: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+
+	r.serveWarnings(rec, httptest.NewRequest(http.MethodGet, "/debug/warnings", nil))
+
+	body, _ := io.ReadAll(rec.Result().Body)
+
+	for _, want := range []string{
+		"<h2>default</h2>",
+		"<td>remote_version</td><td>get_remote_version</td><td>/api/remote_version/</td>",
+		"<h2>quiet&lt;&gt;</h2>\n<p>No warnings.</p>",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("Response %q does not contain %q", body, want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package exporter
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hansmi/prometheus-paperless-exporter/pkg/collector"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const scrapeTimeoutHeader = "X-Prometheus-Scrape-Timeout-Seconds"
+
+// scrapeContext returns a context for the request. Its deadline is derived
+// from the scrape timeout announced by Prometheus minus the given offset.
+func scrapeContext(r *http.Request, offset time.Duration) (context.Context, context.CancelFunc) {
+	ctx := r.Context()
+
+	if value := r.Header.Get(scrapeTimeoutHeader); value != "" {
+		if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+			timeout := time.Duration(seconds * float64(time.Second))
+
+			if timeout > offset {
+				timeout -= offset
+			}
+
+			return context.WithTimeout(ctx, timeout)
+		}
+	}
+
+	return context.WithCancel(ctx)
+}
+
+// serveMetrics responds with the metrics of the given collector and all extra
+// gatherers. The "collect[]" and "exclude[]" query parameters select a subset
+// of the collector's members.
+func serveMetrics(w http.ResponseWriter, r *http.Request, c *collector.Collector, extra ...prometheus.Gatherer) {
+	gatherers := prometheus.Gatherers(extra)
+
+	if c != nil {
+		query := r.URL.Query()
+
+		filtered, err := c.Filter(query["collect[]"], query["exclude[]"])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := scrapeContext(r, filtered.TimeoutOffset())
+		defer cancel()
+
+		g, err := filtered.Gatherer(ctx)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		gatherers = append(gatherers, g)
+	}
+
+	promhttp.HandlerFor(gatherers, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+}
@@ -0,0 +1,190 @@
+package exporter
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hansmi/paperhooks/pkg/client"
+	"github.com/hansmi/prometheus-paperless-exporter/pkg/collector"
+)
+
+// newFakePaperless returns a client for a server responding with the given
+// JSON documents keyed by API path.
+func newFakePaperless(t *testing.T, responses map[string]string) *client.Client {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.Handle("/", http.NotFoundHandler())
+
+	for path, body := range responses {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, body)
+		})
+	}
+
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	cl, err := buildClient(client.Flags{BaseURL: ts.URL}, nil)
+	if err != nil {
+		t.Fatalf("buildClient() failed: %v", err)
+	}
+
+	return cl
+}
+
+func TestServeMetrics(t *testing.T) {
+	cl := newFakePaperless(t, map[string]string{
+		"/api/tags/":   `{"count": 1, "results": [{"id": 1}]}`,
+		"/api/groups/": `{"count": 2, "results": []}`,
+		"/api/users/":  `{"count": 3, "results": []}`,
+	})
+
+	c, err := collector.New(cl, collector.Options{
+		EnabledIDs: []string{"tag", "group", "user"},
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name       string
+		query      string
+		wantStatus int
+		want       []string
+		wantNot    []string
+	}{
+		{
+			name:       "all",
+			wantStatus: http.StatusOK,
+			want:       []string{"paperless_tag_info", "paperless_groups 2", "paperless_users 3"},
+		},
+		{
+			name:       "collect",
+			query:      "collect[]=group&collect[]=user",
+			wantStatus: http.StatusOK,
+			want:       []string{"paperless_groups 2", "paperless_users 3"},
+			wantNot:    []string{"paperless_tag_info"},
+		},
+		{
+			name:       "exclude",
+			query:      "exclude[]=tag",
+			wantStatus: http.StatusOK,
+			want:       []string{"paperless_groups 2", "paperless_users 3"},
+			wantNot:    []string{"paperless_tag_info"},
+		},
+		{
+			name:       "collect and exclude",
+			query:      "collect[]=tag&collect[]=user&exclude[]=user",
+			wantStatus: http.StatusOK,
+			want:       []string{"paperless_tag_info"},
+			wantNot:    []string{"paperless_groups", "paperless_users"},
+		},
+		{
+			name:       "unknown",
+			query:      "collect[]=unknown",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "not enabled",
+			query:      "exclude[]=status",
+			wantStatus: http.StatusBadRequest,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+
+			serveMetrics(rec, httptest.NewRequest(http.MethodGet, "/metrics?"+tc.query, nil), c)
+
+			if diff := cmp.Diff(tc.wantStatus, rec.Code); diff != "" {
+				t.Errorf("Status diff (-want +got):\n%s", diff)
+			}
+
+			body := rec.Body.String()
+
+			for _, i := range tc.want {
+				if !strings.Contains(body, i) {
+					t.Errorf("Body %q does not contain %q", body, i)
+				}
+			}
+
+			for _, i := range tc.wantNot {
+				if strings.Contains(body, i) {
+					t.Errorf("Body %q contains %q", body, i)
+				}
+			}
+		})
+	}
+}
+
+func TestScrapeContext(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		header       string
+		offset       time.Duration
+		wantDeadline bool
+		wantTimeout  time.Duration
+	}{
+		{name: "no header"},
+		{name: "invalid", header: "foo"},
+		{name: "zero", header: "0"},
+		{
+			name:         "timeout",
+			header:       "10",
+			wantDeadline: true,
+			wantTimeout:  10 * time.Second,
+		},
+		{
+			name:         "with offset",
+			header:       "2.5",
+			offset:       500 * time.Millisecond,
+			wantDeadline: true,
+			wantTimeout:  2 * time.Second,
+		},
+		{
+			name:         "offset too large",
+			header:       "0.25",
+			offset:       time.Second,
+			wantDeadline: true,
+			wantTimeout:  250 * time.Millisecond,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+
+			if tc.header != "" {
+				req.Header.Set(scrapeTimeoutHeader, tc.header)
+			}
+
+			start := time.Now()
+
+			ctx, cancel := scrapeContext(req, tc.offset)
+			t.Cleanup(cancel)
+
+			deadline, ok := ctx.Deadline()
+
+			if ok != tc.wantDeadline {
+				t.Errorf("Deadline() returned %v, want %v", ok, tc.wantDeadline)
+			}
+
+			if ok {
+				if got := deadline.Sub(start); got > tc.wantTimeout+time.Second || got < tc.wantTimeout-time.Second {
+					t.Errorf("Timeout is %v, want %v", got, tc.wantTimeout)
+				}
+			}
+
+			cancel()
+
+			if err := ctx.Err(); err != context.Canceled {
+				t.Errorf("Context error %v, want cancellation", err)
+			}
+		})
+	}
+}
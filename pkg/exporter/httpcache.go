@@ -0,0 +1,279 @@
+package exporter
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Responses with larger bodies are not cached.
+const httpCacheMaxBodySize = 8 << 20
+
+// Maximum combined size of all cached response bodies.
+const httpCacheMaxSize = 64 << 20
+
+type httpCacheEntry struct {
+	key      string
+	storedAt time.Time
+
+	statusCode int
+	header     http.Header
+	body       []byte
+}
+
+func (e *httpCacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.statusCode, http.StatusText(e.statusCode)),
+		StatusCode:    e.statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
+
+// httpCache stores responses of the configured API endpoints. Responses with
+// an ETag or Last-Modified header are revalidated using conditional requests.
+// With a TTL responses are served from the cache without contacting the
+// server until they expire.
+type httpCache struct {
+	ttl        time.Duration
+	endpoints  []string
+	maxEntries int
+	maxBytes   int
+	now        func() time.Time
+
+	hits   *prometheus.CounterVec
+	misses prometheus.Counter
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+
+	// Cached entries from the least to the most recently stored.
+	order *list.List
+
+	// Combined size of all cached bodies.
+	size int
+}
+
+var _ prometheus.Collector = (*httpCache)(nil)
+
+// newHTTPCache returns a cache for requests whose path starts with one of the
+// given endpoint prefixes. A zero TTL disables TTL-based caching.
+func newHTTPCache(ttl time.Duration, endpoints []string) *httpCache {
+	return &httpCache{
+		ttl:        ttl,
+		endpoints:  endpoints,
+		maxEntries: 1000,
+		maxBytes:   httpCacheMaxSize,
+		now:        time.Now,
+		hits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "paperless_exporter_http_cache_hits_total",
+			Help: "Number of API responses served from the cache, either without contacting the server (ttl) or after revalidation (revalidated).",
+		}, []string{"type"}),
+		misses: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "paperless_exporter_http_cache_misses_total",
+			Help: "Number of API requests to cached endpoints not served from the cache.",
+		}),
+		entries: map[string]*list.Element{},
+		order:   list.New(),
+	}
+}
+
+func (c *httpCache) Describe(ch chan<- *prometheus.Desc) {
+	c.hits.Describe(ch)
+	c.misses.Describe(ch)
+}
+
+func (c *httpCache) Collect(ch chan<- prometheus.Metric) {
+	c.hits.Collect(ch)
+	c.misses.Collect(ch)
+}
+
+// matches reports whether the request is for one of the cached endpoints.
+func (c *httpCache) matches(req *http.Request) bool {
+	for _, prefix := range c.endpoints {
+		if strings.HasPrefix(req.URL.Path, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cacheKey identifies a request. Credentials are part of the key to keep
+// responses for different users apart.
+func cacheKey(req *http.Request) string {
+	return strings.Join([]string{
+		req.URL.String(),
+		req.Header.Get("Accept"),
+		req.Header.Get("Authorization"),
+	}, "\x00")
+}
+
+func (c *httpCache) lookup(key string) *httpCacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		return elem.Value.(*httpCacheEntry)
+	}
+
+	return nil
+}
+
+func (c *httpCache) remove(elem *list.Element) {
+	e := c.order.Remove(elem).(*httpCacheEntry)
+
+	c.size -= len(e.body)
+	delete(c.entries, e.key)
+}
+
+// store adds an entry to the cache. The oldest entries are evicted to stay
+// within the limits. Entries larger than the maximum size are not stored.
+func (c *httpCache) store(key string, e *httpCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+
+	if len(e.body) > c.maxBytes {
+		return
+	}
+
+	for c.order.Len() > 0 && (c.order.Len() >= c.maxEntries || c.size+len(e.body) > c.maxBytes) {
+		c.remove(c.order.Front())
+	}
+
+	e.key = key
+
+	c.entries[key] = c.order.PushBack(e)
+	c.size += len(e.body)
+}
+
+// wrap returns a transport using the cache.
+func (c *httpCache) wrap(base http.RoundTripper) http.RoundTripper {
+	return &cachingTransport{cache: c, base: base}
+}
+
+type cachingTransport struct {
+	cache *httpCache
+	base  http.RoundTripper
+}
+
+var _ http.RoundTripper = (*cachingTransport)(nil)
+
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c := t.cache
+
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" || !c.matches(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	key := cacheKey(req)
+	ttl := c.ttl
+	entry := c.lookup(key)
+
+	if entry != nil && ttl > 0 && c.now().Sub(entry.storedAt) < ttl {
+		c.hits.WithLabelValues("ttl").Inc()
+		return entry.response(req), nil
+	}
+
+	// Every request reaching the server is a miss unless it's revalidated,
+	// including failed and uncacheable ones.
+	revalidated := false
+
+	defer func() {
+		if !revalidated {
+			c.misses.Inc()
+		}
+	}()
+
+	outreq := req
+
+	if entry != nil {
+		etag := entry.header.Get("ETag")
+		lastModified := entry.header.Get("Last-Modified")
+
+		if etag != "" || lastModified != "" {
+			outreq = req.Clone(req.Context())
+
+			if etag != "" {
+				outreq.Header.Set("If-None-Match", etag)
+			}
+
+			if lastModified != "" {
+				outreq.Header.Set("If-Modified-Since", lastModified)
+			}
+		}
+	}
+
+	resp, err := t.base.RoundTrip(outreq)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry != nil && outreq != req && resp.StatusCode == http.StatusNotModified {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		refreshed := *entry
+		refreshed.storedAt = c.now()
+
+		c.store(key, &refreshed)
+		c.hits.WithLabelValues("revalidated").Inc()
+
+		revalidated = true
+
+		return refreshed.response(req), nil
+	}
+
+	cacheable := resp.StatusCode == http.StatusOK &&
+		(ttl > 0 || resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != "") &&
+		!strings.Contains(resp.Header.Get("Cache-Control"), "no-store")
+
+	if !cacheable {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, httpCacheMaxBodySize+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	if len(body) > httpCacheMaxBodySize {
+		// Pass the response through without caching.
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+
+		return resp, nil
+	}
+
+	resp.Body.Close()
+
+	entry = &httpCacheEntry{
+		storedAt:   c.now(),
+		statusCode: resp.StatusCode,
+		header:     resp.Header.Clone(),
+		body:       body,
+	}
+
+	c.store(key, entry)
+
+	return entry.response(req), nil
+}
@@ -0,0 +1,257 @@
+package exporter
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hansmi/prometheus-paperless-exporter/internal/testutil"
+)
+
+func TestHTTPCache(t *testing.T) {
+	calls := map[string]int{}
+	conditional := map[string]int{}
+	content := "first"
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls[r.URL.Path]++
+
+		switch r.URL.Path {
+		case "/api/tags/", "/api/correspondents/":
+			etag := `"` + content + `"`
+
+			if r.Header.Get("If-None-Match") != "" {
+				conditional[r.URL.Path]++
+			}
+
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+			w.Header().Set("ETag", etag)
+
+		case "/api/missing/":
+			http.NotFound(w, r)
+			return
+		}
+
+		io.WriteString(w, content)
+	}))
+	t.Cleanup(srv.Close)
+
+	now := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	c := newHTTPCache(time.Minute, []string{"/api/tags/", "/api/missing/"})
+	c.now = func() time.Time { return now }
+
+	hc := &http.Client{Transport: c.wrap(http.DefaultTransport)}
+
+	get := func(path string) string {
+		t.Helper()
+
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, srv.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := hc.Do(req)
+		if err != nil {
+			t.Fatalf("Request for %q failed: %v", path, err)
+		}
+
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return string(body)
+	}
+
+	for range 3 {
+		get("/api/tags/")
+		get("/api/correspondents/")
+		get("/api/statistics/")
+		get("/api/missing/")
+	}
+
+	// Only the configured endpoints are cached.
+	if diff := cmp.Diff(map[string]int{
+		"/api/tags/":           1,
+		"/api/correspondents/": 3,
+		"/api/statistics/":     3,
+		"/api/missing/":        3,
+	}, calls); diff != "" {
+		t.Errorf("Server calls diff (-want +got):\n%s", diff)
+	}
+
+	// Expired entries are revalidated.
+	now = now.Add(time.Minute)
+
+	if diff := cmp.Diff("first", get("/api/tags/")); diff != "" {
+		t.Errorf("Body diff (-want +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff(map[string]int{"/api/tags/": 1}, conditional); diff != "" {
+		t.Errorf("Conditional requests diff (-want +got):\n%s", diff)
+	}
+
+	// Changed content is only visible after the TTL expired.
+	content = "second"
+
+	if diff := cmp.Diff("first", get("/api/tags/")); diff != "" {
+		t.Errorf("Body diff (-want +got):\n%s", diff)
+	}
+
+	now = now.Add(time.Minute)
+
+	if diff := cmp.Diff("second", get("/api/tags/")); diff != "" {
+		t.Errorf("Body diff (-want +got):\n%s", diff)
+	}
+
+	// Responses served from the cache look like those from the server.
+	resp, err := hc.Get(srv.URL + "/api/tags/")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+
+	resp.Body.Close()
+
+	if diff := cmp.Diff("200 OK", resp.Status); diff != "" {
+		t.Errorf("Status diff (-want +got):\n%s", diff)
+	}
+
+	// Uncacheable responses of cached endpoints are misses.
+	testutil.CollectAndCompare(t, c, `
+# HELP paperless_exporter_http_cache_hits_total Number of API responses served from the cache, either without contacting the server (ttl) or after revalidation (revalidated).
+# TYPE paperless_exporter_http_cache_hits_total counter
+paperless_exporter_http_cache_hits_total{type="revalidated"} 1
+paperless_exporter_http_cache_hits_total{type="ttl"} 4
+# HELP paperless_exporter_http_cache_misses_total Number of API requests to cached endpoints not served from the cache.
+# TYPE paperless_exporter_http_cache_misses_total counter
+paperless_exporter_http_cache_misses_total 5
+`)
+}
+
+func TestHTTPCacheEviction(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.URL.Path)
+	}))
+	t.Cleanup(srv.Close)
+
+	now := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	c := newHTTPCache(time.Hour, []string{"/"})
+	c.maxEntries = 2
+	c.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	rt := c.wrap(http.DefaultTransport)
+
+	for _, path := range []string{"/a", "/b", "/c"} {
+		if err := doRequest(context.Background(), t, rt, srv.URL+path); err != nil {
+			t.Errorf("Request failed: %v", err)
+		}
+	}
+
+	if got := len(c.entries); got != c.maxEntries {
+		t.Errorf("Cache has %d entries, want %d", got, c.maxEntries)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/a", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.lookup(cacheKey(req)) != nil {
+		t.Errorf("Oldest entry was not evicted")
+	}
+}
+
+func TestHTTPCacheStoreOrder(t *testing.T) {
+	c := newHTTPCache(time.Hour, []string{"/"})
+	c.maxEntries = 2
+
+	for _, key := range []string{"a", "b", "a", "c"} {
+		c.store(key, &httpCacheEntry{body: []byte(key)})
+	}
+
+	// Storing an entry again makes it the most recent one.
+	if diff := cmp.Diff(map[string]bool{
+		"a": true,
+		"b": false,
+		"c": true,
+	}, map[string]bool{
+		"a": c.lookup("a") != nil,
+		"b": c.lookup("b") != nil,
+		"c": c.lookup("c") != nil,
+	}); diff != "" {
+		t.Errorf("Cached entries diff (-want +got):\n%s", diff)
+	}
+
+	if c.size != 2 || c.order.Len() != 2 {
+		t.Errorf("Cache has %d entries of %d bytes, want 2 of 2 bytes", c.order.Len(), c.size)
+	}
+}
+
+func TestHTTPCacheSizeLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, strings.Repeat("x", len(r.URL.Path)))
+	}))
+	t.Cleanup(srv.Close)
+
+	now := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	c := newHTTPCache(time.Hour, []string{"/"})
+	c.maxBytes = 10
+	c.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	rt := c.wrap(http.DefaultTransport)
+
+	cached := func(path string) bool {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return c.lookup(cacheKey(req)) != nil
+	}
+
+	for _, path := range []string{"/aaaa", "/bbb", "/cc", "/too-large-to-cache"} {
+		if err := doRequest(context.Background(), t, rt, srv.URL+path); err != nil {
+			t.Errorf("Request failed: %v", err)
+		}
+	}
+
+	if diff := cmp.Diff(map[string]bool{
+		"/aaaa":               false,
+		"/bbb":                true,
+		"/cc":                 true,
+		"/too-large-to-cache": false,
+	}, map[string]bool{
+		"/aaaa":               cached("/aaaa"),
+		"/bbb":                cached("/bbb"),
+		"/cc":                 cached("/cc"),
+		"/too-large-to-cache": cached("/too-large-to-cache"),
+	}); diff != "" {
+		t.Errorf("Cached entries diff (-want +got):\n%s", diff)
+	}
+
+	if c.size != 7 {
+		t.Errorf("Cache size is %d, want 7", c.size)
+	}
+}
@@ -0,0 +1,357 @@
+// Package exporter implements the prometheus-paperless-exporter command. Custom
+// builds with additional collectors register them from an init function and
+// call [Main].
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/hansmi/paperhooks/pkg/client"
+	"github.com/hansmi/paperhooks/pkg/kpflag"
+	"github.com/hansmi/prometheus-paperless-exporter/pkg/collector"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/collectors/version"
+	"github.com/prometheus/common/promslog"
+	promslogflag "github.com/prometheus/common/promslog/flag"
+	"github.com/prometheus/exporter-toolkit/web"
+	webflag "github.com/prometheus/exporter-toolkit/web/kingpinflag"
+)
+
+// flags contains the command line settings of the exporter.
+type flags struct {
+	webConfig              *web.FlagConfig
+	metricsPath            string
+	disableExporterMetrics bool
+	enableRemoteNetwork    bool
+	timeout                time.Duration
+	timeoutOffset          time.Duration
+	backgroundInterval     time.Duration
+	configFile             string
+	apiMaxConcurrency      int
+	apiRequestsPerSecond   float64
+	apiMaxRetries          int
+	apiCircuitThreshold    int
+	apiCircuitCooldown     time.Duration
+	apiCacheTTL            time.Duration
+	apiCacheEndpoints      []string
+	tracingOTLPEndpoint    string
+	tracingFile            string
+	collectors             string
+
+	serveCommand *kingpin.CmdClause
+
+	collectCommand *kingpin.CmdClause
+	collectOutput  string
+	collectFormat  string
+
+	pushCommand            *kingpin.CmdClause
+	pushURL                string
+	pushMode               string
+	pushInterval           time.Duration
+	pushJob                string
+	pushUsername           string
+	pushPasswordFile       string
+	pushBearerTokenFile    string
+	pushCAFile             string
+	pushCertFile           string
+	pushKeyFile            string
+	pushServerName         string
+	pushInsecureSkipVerify bool
+}
+
+// registerFlags adds the flags and commands of the exporter to the
+// application.
+func registerFlags(app *kingpin.Application) *flags {
+	f := &flags{}
+
+	f.webConfig = webflag.AddFlags(app, ":8081")
+	app.Flag("web.telemetry-path", "Path under which to expose metrics").Default("/metrics").StringVar(&f.metricsPath)
+	app.Flag("web.disable-exporter-metrics", "Exclude metrics about the exporter itself").BoolVar(&f.disableExporterMetrics)
+	app.Flag("enable-remote-network", "Include calls to API endpoints that require public internet access for your paperless instance (e.g. checking for a paperless version). Shorthand for --collector.remote_version.").BoolVar(&f.enableRemoteNetwork)
+	app.Flag("scrape-timeout", "Maximum duration for a scrape").Default("1m").DurationVar(&f.timeout)
+	app.Flag("scrape-timeout-offset", "Offset to subtract from the scrape timeout announced by Prometheus via the "+scrapeTimeoutHeader+" header").Default("500ms").DurationVar(&f.timeoutOffset)
+	app.Flag("background-interval", "Collect metrics in the background at the given interval and serve scrapes from the most recent results. Disabled if zero.").Default("0").DurationVar(&f.backgroundInterval)
+	app.Flag("config.file", "Path to a YAML configuration file. Reloaded on SIGHUP or POST requests to /-/reload.").ExistingFileVar(&f.configFile)
+	app.Flag("api.max-concurrency", "Maximum number of concurrent requests to the Paperless API. Unlimited if zero.").Default("0").IntVar(&f.apiMaxConcurrency)
+	app.Flag("api.requests-per-second", "Maximum number of requests per second to the Paperless API. Unlimited if zero.").Default("0").Float64Var(&f.apiRequestsPerSecond)
+	app.Flag("api.max-retries", "Maximum number of retries for API requests failing with transient errors. Retries never exceed the scrape deadline.").Default("2").IntVar(&f.apiMaxRetries)
+	app.Flag("api.circuit-breaker.threshold", "Number of consecutive failed API requests after which requests are suspended. Disabled if zero.").Default("5").IntVar(&f.apiCircuitThreshold)
+	app.Flag("api.circuit-breaker.cooldown", "Duration for which API requests are suspended after repeated failures.").Default("30s").DurationVar(&f.apiCircuitCooldown)
+	app.Flag("api.cache-ttl", "Serve responses for the endpoints given via --api.cache-endpoint from a cache for the given duration. Disabled if zero.").Default("0").DurationVar(&f.apiCacheTTL)
+	app.Flag("api.cache-endpoint", "API path prefix whose responses are cached. Can be given multiple times.").Default("/api/tags/", "/api/document_types/", "/api/storage_paths/").StringsVar(&f.apiCacheEndpoints)
+	app.Flag("tracing.otlp-endpoint", "URL of an OTLP/HTTP endpoint receiving trace spans for scrapes and API requests, e.g. http://localhost:4318/v1/traces.").StringVar(&f.tracingOTLPEndpoint)
+	app.Flag("tracing.file", "Append trace spans for scrapes and API requests as JSON to the given file.").StringVar(&f.tracingFile)
+
+	f.serveCommand = app.Command("serve", "Serve metrics via HTTP.").Default()
+
+	f.collectCommand = app.Command("collect", "Collect metrics once and write them to standard output or a file, e.g. for the textfile collector of node_exporter.")
+	f.collectCommand.Flag("output", "Destination file, replaced atomically. Standard output if empty or \"-\".").Short('o').StringVar(&f.collectOutput)
+	f.collectCommand.Flag("format", "Exposition format.").Default("text").EnumVar(&f.collectFormat, "text", "openmetrics")
+
+	f.pushCommand = app.Command("push", "Collect metrics at an interval and push them to a Pushgateway or a remote-write receiver.")
+	f.pushCommand.Flag("url", "Pushgateway base URL or remote-write endpoint, e.g. http://pushgateway:9091 or http://prometheus:9090/api/v1/write.").Required().StringVar(&f.pushURL)
+	f.pushCommand.Flag("mode", "Push protocol.").Default(pushModePushgateway).EnumVar(&f.pushMode, pushModePushgateway, pushModeRemoteWrite)
+	f.pushCommand.Flag("interval", "Duration between pushes.").Default("1m").DurationVar(&f.pushInterval)
+	f.pushCommand.Flag("job", "Job name used for the Pushgateway grouping key and as the job label of remote writes.").Default("paperless").StringVar(&f.pushJob)
+	f.pushCommand.Flag("basic-auth.username", "Username for HTTP basic authentication.").StringVar(&f.pushUsername)
+	f.pushCommand.Flag("basic-auth.password-file", "File containing the password for HTTP basic authentication.").ExistingFileVar(&f.pushPasswordFile)
+	f.pushCommand.Flag("bearer-token-file", "File containing a bearer token sent in the Authorization header.").ExistingFileVar(&f.pushBearerTokenFile)
+	f.pushCommand.Flag("tls.ca-file", "CA certificates for verifying the server certificate.").ExistingFileVar(&f.pushCAFile)
+	f.pushCommand.Flag("tls.cert-file", "Client certificate for TLS authentication.").ExistingFileVar(&f.pushCertFile)
+	f.pushCommand.Flag("tls.key-file", "Private key of the client certificate.").ExistingFileVar(&f.pushKeyFile)
+	f.pushCommand.Flag("tls.server-name", "Server name for verifying the server certificate.").StringVar(&f.pushServerName)
+	f.pushCommand.Flag("tls.insecure-skip-verify", "Disable verification of the server certificate.").BoolVar(&f.pushInsecureSkipVerify)
+
+	app.Flag("collectors", "Comma-separated list of collectors to enable instead of the default collectors. Applied before --[no-]collector.<id> flags.").StringVar(&f.collectors)
+
+	return f
+}
+
+// registerCollectorFlags adds flags for enabling collectors and per-collector
+// settings. The returned function applies them to collector options after flag
+// parsing.
+func registerCollectorFlags(app *kingpin.Application) func(*collector.Options) {
+	type flags struct {
+		enabled         *bool
+		enabledSet      bool
+		refreshInterval *time.Duration
+		timeout         *time.Duration
+	}
+
+	all := map[string]*flags{}
+
+	for _, id := range collector.IDs() {
+		info, _ := collector.Lookup(id)
+		f := &flags{}
+
+		state := "disabled"
+
+		if info.DefaultEnabled {
+			state = "enabled"
+		}
+
+		help := fmt.Sprintf("Enable the %q collector (default: %s).", id, state)
+
+		if len(info.Permissions) > 0 {
+			help += fmt.Sprintf(" Requires view permissions on: %s.", strings.Join(info.Permissions, ", "))
+		}
+
+		enabledFlag := app.Flag("collector."+id, help).
+			Default(strconv.FormatBool(info.DefaultEnabled))
+		enabledFlag.IsSetByUser(&f.enabledSet)
+
+		f.enabled = enabledFlag.Bool()
+		f.refreshInterval = app.Flag(fmt.Sprintf("collector.%s.refresh-interval", id),
+			fmt.Sprintf("Minimum duration between refreshes of the %q collector. Scrapes in between are served from cached data.", id)).Default("0").Duration()
+		f.timeout = app.Flag(fmt.Sprintf("collector.%s.timeout", id),
+			fmt.Sprintf("Timeout for refreshing the %q collector. Previous results are reported as stale on expiry.", id)).Default("0").Duration()
+
+		all[id] = f
+	}
+
+	return func(opts *collector.Options) {
+		opts.Overrides = map[string]bool{}
+		opts.Members = map[string]collector.MemberOptions{}
+
+		for id, f := range all {
+			if f.enabledSet {
+				opts.Overrides[id] = *f.enabled
+			}
+
+			opts.Members[id] = collector.MemberOptions{
+				RefreshInterval: *f.refreshInterval,
+				Timeout:         *f.timeout,
+			}
+		}
+	}
+}
+
+// Main parses the command line and runs the exporter. Collectors registered
+// via [collector.Register] beforehand are available for selection. Flags are
+// defined on a dedicated application, not on [kingpin.CommandLine].
+func Main() {
+	var clientFlags client.Flags
+
+	app := kingpin.New(filepath.Base(os.Args[0]), "")
+
+	f := registerFlags(app)
+
+	promslogConfig := &promslog.Config{}
+	promslogflag.AddFlags(app, promslogConfig)
+
+	kpflag.RegisterClient(app, &clientFlags)
+	applyCollectorFlags := registerCollectorFlags(app)
+	command := kingpin.MustParse(app.Parse(os.Args[1:]))
+
+	logger := promslog.New(promslogConfig)
+
+	var enabledCollectors []string
+
+	// Parse comma-separated collectors flag into a slice.
+	for _, s := range strings.Split(strings.TrimSpace(f.collectors), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			enabledCollectors = append(enabledCollectors, s)
+		}
+	}
+
+	collapsedScrapes := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "paperless_exporter_collapsed_scrapes_total",
+		Help: "Number of scrapes served from a collection run started by a concurrent scrape.",
+	})
+
+	tp, shutdownTracing, err := newTracerProvider(context.Background(), tracingOptions{
+		otlpEndpoint: f.tracingOTLPEndpoint,
+		file:         f.tracingFile,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer shutdownTracing(context.Background())
+
+	apiMetrics := newAPIMetrics()
+	breaker := newCircuitBreaker(f.apiCircuitThreshold, f.apiCircuitCooldown)
+	cache := newHTTPCache(f.apiCacheTTL, f.apiCacheEndpoints)
+
+	opts := collector.Options{
+		Timeout:            f.timeout,
+		TimeoutOffset:      f.timeoutOffset,
+		EnabledIDs:         enabledCollectors,
+		BackgroundInterval: f.backgroundInterval,
+		CollapsedScrapes:   collapsedScrapes,
+		Logger:             logger,
+		TracerProvider:     tp,
+	}
+
+	wrapTransport := chainTransports(
+		newAPITracer(tp).wrap,
+		cache.wrap,
+		breaker.wrap,
+		newRetryPolicy(f.apiMaxRetries).wrap,
+		newAPILimiter(f.apiMaxConcurrency, f.apiRequestsPerSecond).wrap,
+		apiMetrics.wrap,
+	)
+
+	applyCollectorFlags(&opts)
+
+	if _, ok := opts.Overrides["remote_version"]; f.enableRemoteNetwork && !ok {
+		opts.Overrides["remote_version"] = true
+	}
+
+	loadConfig := func() (*config, error) {
+		if f.configFile == "" {
+			return &config{}, nil
+		}
+
+		return loadConfigFile(f.configFile)
+	}
+
+	if command == f.collectCommand.FullCommand() {
+		cfg, err := loadConfig()
+		if err == nil {
+			err = runCollect(cfg, opts, wrapTransport, clientFlags, collectOptions{
+				output: f.collectOutput,
+				format: f.collectFormat,
+			})
+		}
+
+		shutdownTracing(context.Background())
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		return
+	}
+
+	if command == f.pushCommand.FullCommand() {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+		cfg, err := loadConfig()
+		if err == nil {
+			err = runPush(ctx, logger, cfg, opts, wrapTransport, clientFlags, pushOptions{
+				url:      f.pushURL,
+				mode:     f.pushMode,
+				interval: f.pushInterval,
+				job:      f.pushJob,
+				httpConfig: pushAuthOptions{
+					username:           f.pushUsername,
+					passwordFile:       f.pushPasswordFile,
+					bearerTokenFile:    f.pushBearerTokenFile,
+					caFile:             f.pushCAFile,
+					certFile:           f.pushCertFile,
+					keyFile:            f.pushKeyFile,
+					serverName:         f.pushServerName,
+					insecureSkipVerify: f.pushInsecureSkipVerify,
+				}.httpConfig(),
+			})
+		}
+
+		stop()
+		shutdownTracing(context.Background())
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		return
+	}
+
+	rel := newReloader(logger, func(prev *exporterState) (*exporterState, error) {
+		cfg, err := loadConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		// The default instance is optional when a configuration file is
+		// used.
+		return newExporterState(cfg, opts, wrapTransport, clientFlags, f.configFile == "", prev)
+	})
+
+	if err := rel.reload(); err != nil {
+		log.Fatal(err)
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go rel.watchSignals(context.Background(), hup)
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(rel.successGauge, rel.successTimeGauge, collapsedScrapes, apiMetrics, breaker, cache)
+
+	if !f.disableExporterMetrics {
+		reg.MustRegister(
+			collectors.NewBuildInfoCollector(),
+			collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+			collectors.NewGoCollector(),
+			version.NewCollector("prometheus_paperless_exporter"),
+		)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(f.metricsPath, rel.metricsHandler(reg))
+	mux.HandleFunc("/probe", rel.serveProbe)
+	mux.HandleFunc("/-/reload", rel.serveReload)
+	mux.HandleFunc("/debug/warnings", rel.serveWarnings)
+	mux.HandleFunc("/api/status", rel.serveStatusAPI)
+	mux.Handle("/", rel.statusPageHandler(f.metricsPath))
+
+	server := &http.Server{Handler: mux}
+
+	if err := web.ListenAndServe(server, f.webConfig, logger); err != nil {
+		log.Fatal(err)
+	}
+}
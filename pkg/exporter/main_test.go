@@ -0,0 +1,78 @@
+package exporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRegisterFlags(t *testing.T) {
+	// Importing the package must not register flags globally.
+	if f := kingpin.CommandLine.GetFlag("web.telemetry-path"); f != nil {
+		t.Errorf("Flag registered on global application: %v", f)
+	}
+
+	for _, tc := range []struct {
+		name        string
+		args        []string
+		wantCommand string
+		check       func(*testing.T, *flags)
+	}{
+		{
+			name:        "defaults",
+			wantCommand: "serve",
+			check: func(t *testing.T, f *flags) {
+				if diff := cmp.Diff("/metrics", f.metricsPath); diff != "" {
+					t.Errorf("Metrics path diff (-want +got):\n%s", diff)
+				}
+
+				if diff := cmp.Diff(time.Minute, f.timeout); diff != "" {
+					t.Errorf("Timeout diff (-want +got):\n%s", diff)
+				}
+			},
+		},
+		{
+			name:        "collect",
+			args:        []string{"--collectors=tag,group", "collect", "-o", "out.prom"},
+			wantCommand: "collect",
+			check: func(t *testing.T, f *flags) {
+				if diff := cmp.Diff("tag,group", f.collectors); diff != "" {
+					t.Errorf("Collectors diff (-want +got):\n%s", diff)
+				}
+
+				if diff := cmp.Diff("out.prom", f.collectOutput); diff != "" {
+					t.Errorf("Output diff (-want +got):\n%s", diff)
+				}
+			},
+		},
+		{
+			name:        "push",
+			args:        []string{"push", "--url=http://localhost:9091", "--mode=remote-write"},
+			wantCommand: "push",
+			check: func(t *testing.T, f *flags) {
+				if diff := cmp.Diff(pushModeRemoteWrite, f.pushMode); diff != "" {
+					t.Errorf("Mode diff (-want +got):\n%s", diff)
+				}
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			app := kingpin.New("test", "")
+
+			f := registerFlags(app)
+
+			command, err := app.Parse(tc.args)
+			if err != nil {
+				t.Fatalf("Parse() failed: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.wantCommand, command); diff != "" {
+				t.Errorf("Command diff (-want +got):\n%s", diff)
+			}
+
+			tc.check(t, f)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+
+	"github.com/hansmi/prometheus-paperless-exporter/pkg/collector"
+)
+
+// probeHandler serves the metrics of one out of multiple Paperless instances
+// selected via the "target" query parameter.
+type probeHandler struct {
+	collectors map[string]*collector.Collector
+
+	// All targets sorted by name.
+	instances []instance
+}
+
+var _ http.Handler = (*probeHandler)(nil)
+
+// newProbeHandler builds a collector with a dedicated client for each target.
+// The wrapper, if non-nil, decorates the HTTP transport of all clients.
+// Collectors of the same targets in the previous handler, if any, are
+// replaced.
+func newProbeHandler(targets map[string]targetConfig, opts collector.Options, wrap transportWrapper, prev *probeHandler) (*probeHandler, error) {
+	h := &probeHandler{
+		collectors: map[string]*collector.Collector{},
+	}
+
+	var previous map[string]*collector.Collector
+
+	if prev != nil {
+		previous = prev.collectors
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(targets)) {
+		t := targets[name]
+
+		cl, err := t.buildClient(wrap)
+		if err != nil {
+			return nil, fmt.Errorf("target %q: %w", name, err)
+		}
+
+		targetOpts := opts
+		targetOpts.Previous = previous[name]
+
+		c, err := collector.New(cl, targetOpts)
+		if err != nil {
+			return nil, fmt.Errorf("target %q: %w", name, err)
+		}
+
+		h.collectors[name] = c
+		h.instances = append(h.instances, instance{
+			name:      name,
+			baseURL:   t.URL,
+			version:   newVersionCache(cl),
+			collector: c,
+		})
+	}
+
+	return h, nil
+}
+
+// start launches the background loops of all target collectors.
+func (h *probeHandler) start(ctx context.Context) {
+	for _, c := range h.collectors {
+		c.Start(ctx)
+	}
+}
+
+func (h *probeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("target")
+	if name == "" {
+		http.Error(w, "Target parameter is missing", http.StatusBadRequest)
+		return
+	}
+
+	c := h.collectors[name]
+	if c == nil {
+		http.Error(w, fmt.Sprintf("Unknown target %q", name), http.StatusNotFound)
+		return
+	}
+
+	serveMetrics(w, r, c)
+}
@@ -0,0 +1,94 @@
+package exporter
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hansmi/prometheus-paperless-exporter/pkg/collector"
+)
+
+func TestProbeHandler(t *testing.T) {
+	contentTypeJson := mime.FormatMediaType("application/json", nil)
+
+	newServer := func(count string) *httptest.Server {
+		mux := http.NewServeMux()
+		mux.Handle("/", http.NotFoundHandler())
+		mux.HandleFunc("/api/groups/", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", contentTypeJson)
+			io.WriteString(w, `{"count": `+count+`}`)
+		})
+
+		ts := httptest.NewServer(mux)
+		t.Cleanup(ts.Close)
+
+		return ts
+	}
+
+	h, err := newProbeHandler(map[string]targetConfig{
+		"first":  {URL: newServer("12").URL},
+		"second": {URL: newServer("34").URL},
+	}, collector.Options{
+		EnabledIDs: []string{"group"},
+	}, nil, nil)
+	if err != nil {
+		t.Fatalf("newProbeHandler() failed: %v", err)
+	}
+
+	var names []string
+
+	for _, i := range h.instances {
+		names = append(names, i.name)
+	}
+
+	if diff := cmp.Diff([]string{"first", "second"}, names); diff != "" {
+		t.Errorf("Instance names diff (-want +got):\n%s", diff)
+	}
+
+	for _, tc := range []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "missing target",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown target",
+			query:      "target=unknown",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "first",
+			query:      "target=first",
+			wantStatus: http.StatusOK,
+			wantBody:   "paperless_groups 12\n",
+		},
+		{
+			name:       "second",
+			query:      "target=second",
+			wantStatus: http.StatusOK,
+			wantBody:   "paperless_groups 34\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/probe?"+tc.query, nil))
+
+			if diff := cmp.Diff(tc.wantStatus, rec.Code); diff != "" {
+				t.Errorf("Status diff (-want +got):\n%s", diff)
+			}
+
+			if body := rec.Body.String(); !strings.Contains(body, tc.wantBody) {
+				t.Errorf("Body %q does not contain %q", body, tc.wantBody)
+			}
+		})
+	}
+}
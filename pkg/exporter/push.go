@@ -0,0 +1,167 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/hansmi/paperhooks/pkg/client"
+	"github.com/hansmi/prometheus-paperless-exporter/pkg/collector"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	promconfig "github.com/prometheus/common/config"
+)
+
+const (
+	pushModePushgateway = "pushgateway"
+	pushModeRemoteWrite = "remote-write"
+)
+
+// pushOptions configures periodic pushes of collected metrics.
+type pushOptions struct {
+	// Pushgateway base URL or remote-write endpoint.
+	url string
+
+	// Either pushModePushgateway or pushModeRemoteWrite.
+	mode string
+
+	// Duration between pushes.
+	interval time.Duration
+
+	// Job name used as the Pushgateway grouping key and as the "job" label
+	// for remote writes.
+	job string
+
+	// Authentication and TLS settings.
+	httpConfig promconfig.HTTPClientConfig
+}
+
+// pushAuthOptions contains authentication and TLS settings for pushes.
+type pushAuthOptions struct {
+	username           string
+	passwordFile       string
+	bearerTokenFile    string
+	caFile             string
+	certFile           string
+	keyFile            string
+	serverName         string
+	insecureSkipVerify bool
+}
+
+// httpConfig returns an HTTP client configuration using the settings.
+func (o pushAuthOptions) httpConfig() promconfig.HTTPClientConfig {
+	cfg := promconfig.DefaultHTTPClientConfig
+
+	if o.username != "" || o.passwordFile != "" {
+		cfg.BasicAuth = &promconfig.BasicAuth{
+			Username:     o.username,
+			PasswordFile: o.passwordFile,
+		}
+	}
+
+	if o.bearerTokenFile != "" {
+		cfg.Authorization = &promconfig.Authorization{
+			Type:            "Bearer",
+			CredentialsFile: o.bearerTokenFile,
+		}
+	}
+
+	cfg.TLSConfig = promconfig.TLSConfig{
+		CAFile:             o.caFile,
+		CertFile:           o.certFile,
+		KeyFile:            o.keyFile,
+		ServerName:         o.serverName,
+		InsecureSkipVerify: o.insecureSkipVerify,
+	}
+
+	return cfg
+}
+
+// pushFunc sends the metrics of a gatherer to a remote destination.
+type pushFunc func(context.Context, prometheus.Gatherer) error
+
+// newPushFunc returns a function sending metrics as configured.
+func newPushFunc(opts pushOptions) (pushFunc, error) {
+	if opts.url == "" {
+		return nil, errors.New("push URL is required")
+	}
+
+	if err := opts.httpConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("push HTTP configuration: %w", err)
+	}
+
+	hc, err := promconfig.NewClientFromConfig(opts.httpConfig, "push")
+	if err != nil {
+		return nil, err
+	}
+
+	switch opts.mode {
+	case pushModePushgateway:
+		return func(ctx context.Context, g prometheus.Gatherer) error {
+			return push.New(opts.url, opts.job).Gatherer(g).Client(hc).PushContext(ctx)
+		}, nil
+
+	case pushModeRemoteWrite:
+		extra := []remoteWriteLabel{{"job", opts.job}}
+
+		return func(ctx context.Context, g prometheus.Gatherer) error {
+			families, err := g.Gather()
+			if err != nil {
+				return fmt.Errorf("collecting metrics: %w", err)
+			}
+
+			return sendRemoteWrite(ctx, hc, opts.url, remoteWriteSeriesFromFamilies(families, extra, time.Now()))
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported push mode %q", opts.mode)
+}
+
+// pushLoop collects and pushes metrics immediately and then at the given
+// interval until the context is cancelled. Failed pushes are logged.
+func pushLoop(ctx context.Context, logger *slog.Logger, c *collector.Collector, interval time.Duration, fn pushFunc) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if g, err := c.Gatherer(ctx); err != nil {
+			logger.Error("Building gatherer failed", "err", err)
+		} else if err := fn(ctx, g); err != nil && ctx.Err() == nil {
+			logger.Error("Pushing metrics failed", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runPush builds the collector of the default instance and pushes its metrics
+// until the context is cancelled.
+func runPush(ctx context.Context, logger *slog.Logger, cfg *config, opts collector.Options, wrap transportWrapper, clientFlags client.Flags, pushOpts pushOptions) error {
+	if pushOpts.interval <= 0 {
+		return errors.New("push interval must be positive")
+	}
+
+	fn, err := newPushFunc(pushOpts)
+	if err != nil {
+		return err
+	}
+
+	s, err := newExporterState(cfg, opts, wrap, clientFlags, true, nil)
+	if err != nil {
+		return err
+	}
+
+	defer s.cancel()
+
+	logger.Info("Pushing metrics", "mode", pushOpts.mode, "url", redactURL(pushOpts.url), "interval", pushOpts.interval)
+
+	pushLoop(ctx, logger, s.collector, pushOpts.interval, fn)
+
+	return nil
+}
@@ -0,0 +1,443 @@
+package exporter
+
+import (
+	"context"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// decodeWriteRequest parses a remote-write 1.0 WriteRequest message.
+func decodeWriteRequest(t *testing.T, b []byte) []remoteWriteSeries {
+	t.Helper()
+
+	// fields calls fn for every field of the message in b.
+	fields := func(b []byte, fn func(num protowire.Number, typ protowire.Type, value []byte, scalar uint64)) {
+		for len(b) > 0 {
+			num, typ, n := protowire.ConsumeTag(b)
+			if n < 0 {
+				t.Fatalf("Invalid tag: %v", protowire.ParseError(n))
+			}
+
+			b = b[n:]
+
+			switch typ {
+			case protowire.BytesType:
+				v, n := protowire.ConsumeBytes(b)
+				if n < 0 {
+					t.Fatalf("Invalid bytes: %v", protowire.ParseError(n))
+				}
+
+				fn(num, typ, v, 0)
+				b = b[n:]
+
+			case protowire.VarintType:
+				v, n := protowire.ConsumeVarint(b)
+				if n < 0 {
+					t.Fatalf("Invalid varint: %v", protowire.ParseError(n))
+				}
+
+				fn(num, typ, nil, v)
+				b = b[n:]
+
+			case protowire.Fixed64Type:
+				v, n := protowire.ConsumeFixed64(b)
+				if n < 0 {
+					t.Fatalf("Invalid fixed64: %v", protowire.ParseError(n))
+				}
+
+				fn(num, typ, nil, v)
+				b = b[n:]
+
+			default:
+				t.Fatalf("Unexpected wire type %v", typ)
+			}
+		}
+	}
+
+	var result []remoteWriteSeries
+
+	fields(b, func(num protowire.Number, _ protowire.Type, ts []byte, _ uint64) {
+		if num != 1 {
+			t.Fatalf("Unexpected WriteRequest field %d", num)
+		}
+
+		var s remoteWriteSeries
+
+		fields(ts, func(num protowire.Number, _ protowire.Type, value []byte, _ uint64) {
+			switch num {
+			case 1:
+				var l remoteWriteLabel
+
+				fields(value, func(num protowire.Number, _ protowire.Type, value []byte, _ uint64) {
+					switch num {
+					case 1:
+						l.name = string(value)
+					case 2:
+						l.value = string(value)
+					}
+				})
+
+				s.labels = append(s.labels, l)
+
+			case 2:
+				fields(value, func(num protowire.Number, _ protowire.Type, _ []byte, scalar uint64) {
+					switch num {
+					case 1:
+						s.value = math.Float64frombits(scalar)
+					case 2:
+						s.timestamp = int64(scalar)
+					}
+				})
+			}
+		})
+
+		result = append(result, s)
+	})
+
+	return result
+}
+
+var remoteWriteSeriesComparer = cmp.AllowUnexported(remoteWriteSeries{}, remoteWriteLabel{})
+
+func TestRemoteWriteSeriesFromFamilies(t *testing.T) {
+	now := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	ts := now.UnixMilli()
+
+	families := []*dto.MetricFamily{
+		{
+			Name: proto.String("paperless_tag_document_count"),
+			Type: dto.MetricType_GAUGE.Enum(),
+			Metric: []*dto.Metric{
+				{
+					Label: []*dto.LabelPair{{Name: proto.String("id"), Value: proto.String("1")}},
+					Gauge: &dto.Gauge{Value: proto.Float64(7)},
+				},
+			},
+		},
+		{
+			Name: proto.String("duration_seconds"),
+			Type: dto.MetricType_HISTOGRAM.Enum(),
+			Metric: []*dto.Metric{
+				{
+					Label: []*dto.LabelPair{{Name: proto.String("job"), Value: proto.String("custom")}},
+					Histogram: &dto.Histogram{
+						SampleCount: proto.Uint64(3),
+						SampleSum:   proto.Float64(1.5),
+						Bucket: []*dto.Bucket{
+							{UpperBound: proto.Float64(0.5), CumulativeCount: proto.Uint64(2)},
+						},
+					},
+					TimestampMs: proto.Int64(1234),
+				},
+			},
+		},
+		{
+			Name: proto.String("latency"),
+			Type: dto.MetricType_SUMMARY.Enum(),
+			Metric: []*dto.Metric{
+				{
+					Summary: &dto.Summary{
+						SampleCount: proto.Uint64(2),
+						SampleSum:   proto.Float64(4),
+						Quantile: []*dto.Quantile{
+							{Quantile: proto.Float64(0.9), Value: proto.Float64(3)},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	got := remoteWriteSeriesFromFamilies(families, []remoteWriteLabel{{"job", "paperless"}}, now)
+
+	want := []remoteWriteSeries{
+		{labels: []remoteWriteLabel{{"__name__", "paperless_tag_document_count"}, {"id", "1"}, {"job", "paperless"}}, value: 7, timestamp: ts},
+		{labels: []remoteWriteLabel{{"__name__", "duration_seconds_bucket"}, {"job", "custom"}, {"le", "0.5"}}, value: 2, timestamp: 1234},
+		{labels: []remoteWriteLabel{{"__name__", "duration_seconds_bucket"}, {"job", "custom"}, {"le", "+Inf"}}, value: 3, timestamp: 1234},
+		{labels: []remoteWriteLabel{{"__name__", "duration_seconds_sum"}, {"job", "custom"}}, value: 1.5, timestamp: 1234},
+		{labels: []remoteWriteLabel{{"__name__", "duration_seconds_count"}, {"job", "custom"}}, value: 3, timestamp: 1234},
+		{labels: []remoteWriteLabel{{"__name__", "latency"}, {"job", "paperless"}, {"quantile", "0.9"}}, value: 3, timestamp: ts},
+		{labels: []remoteWriteLabel{{"__name__", "latency_sum"}, {"job", "paperless"}}, value: 4, timestamp: ts},
+		{labels: []remoteWriteLabel{{"__name__", "latency_count"}, {"job", "paperless"}}, value: 2, timestamp: ts},
+	}
+
+	if diff := cmp.Diff(want, got, remoteWriteSeriesComparer); diff != "" {
+		t.Errorf("Series diff (-want +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff(want, decodeWriteRequest(t, encodeWriteRequest(got)), remoteWriteSeriesComparer); diff != "" {
+		t.Errorf("Encoding roundtrip diff (-want +got):\n%s", diff)
+	}
+}
+
+// writeServerCA stores the certificate of a TLS test server in a file.
+func writeServerCA(t *testing.T, srv *httptest.Server) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: srv.Certificate().Raw,
+	}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func writeSecretFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "secret")
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestPushRemoteWrite(t *testing.T) {
+	var mu sync.Mutex
+	var received []remoteWriteSeries
+	var authHeader string
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("Reading body failed: %v", err)
+			return
+		}
+
+		if got := r.Header.Get("Content-Encoding"); got != "snappy" {
+			t.Errorf("Content-Encoding is %q, want snappy", got)
+		}
+
+		decoded, err := snappy.Decode(nil, body)
+		if err != nil {
+			t.Errorf("Decompressing body failed: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		authHeader = r.Header.Get("Authorization")
+		received = decodeWriteRequest(t, decoded)
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	fn, err := newPushFunc(pushOptions{
+		url:  srv.URL + "/api/v1/write",
+		mode: pushModeRemoteWrite,
+		job:  "paperless",
+		httpConfig: pushAuthOptions{
+			bearerTokenFile: writeSecretFile(t, "token1234"),
+			caFile:          writeServerCA(t, srv),
+			serverName:      "example.com",
+		}.httpConfig(),
+	})
+	if err != nil {
+		t.Fatalf("newPushFunc() failed: %v", err)
+	}
+
+	g, err := newCollectTestCollector(t, "group").Gatherer(context.Background())
+	if err != nil {
+		t.Fatalf("Gatherer() failed: %v", err)
+	}
+
+	// Series with an empty label value, e.g. after redaction.
+	reg := prometheus.NewPedanticRegistry()
+	info := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "paperless_custom_info",
+		Help: "Test metric.",
+	}, []string{"id", "name"})
+	info.WithLabelValues("other", "").Set(1)
+	reg.MustRegister(info)
+
+	if err := fn(context.Background(), prometheus.Gatherers{g, reg}); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	var foundInfo bool
+
+	for _, s := range received {
+		for _, l := range s.labels {
+			if l.value == "" {
+				t.Errorf("Series %v has label %q with empty value", s.labels, l.name)
+			}
+		}
+
+		if s.labels[0].value == "paperless_custom_info" {
+			foundInfo = true
+
+			if diff := cmp.Diff([]remoteWriteLabel{{"__name__", "paperless_custom_info"}, {"id", "other"}, {"job", "paperless"}}, s.labels, remoteWriteSeriesComparer); diff != "" {
+				t.Errorf("Labels diff (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	if !foundInfo {
+		t.Errorf("Received series %+v lack paperless_custom_info", received)
+	}
+
+	if diff := cmp.Diff("Bearer token1234", authHeader); diff != "" {
+		t.Errorf("Authorization header diff (-want +got):\n%s", diff)
+	}
+
+	var found bool
+
+	for _, s := range received {
+		if diff := cmp.Diff([]remoteWriteLabel{{"__name__", "paperless_groups"}, {"job", "paperless"}}, s.labels, remoteWriteSeriesComparer); diff == "" {
+			found = true
+
+			if s.value != 3 {
+				t.Errorf("Value of %v is %v, want 3", s.labels, s.value)
+			}
+		}
+	}
+
+	if !found {
+		t.Errorf("Received series %+v lack paperless_groups", received)
+	}
+}
+
+func TestPushRemoteWriteError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "out of order sample", http.StatusBadRequest)
+	}))
+	t.Cleanup(srv.Close)
+
+	fn, err := newPushFunc(pushOptions{
+		url:        srv.URL,
+		mode:       pushModeRemoteWrite,
+		httpConfig: pushAuthOptions{}.httpConfig(),
+	})
+	if err != nil {
+		t.Fatalf("newPushFunc() failed: %v", err)
+	}
+
+	err = fn(context.Background(), prometheus.NewRegistry())
+
+	if err == nil || !strings.Contains(err.Error(), "out of order sample") {
+		t.Errorf("Push error is %v, want error with response body", err)
+	}
+}
+
+func TestPushPushgateway(t *testing.T) {
+	requests := make(chan *http.Request, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- r
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	fn, err := newPushFunc(pushOptions{
+		url:  srv.URL,
+		mode: pushModePushgateway,
+		job:  "paperless",
+		httpConfig: pushAuthOptions{
+			username:     "pusher",
+			passwordFile: writeSecretFile(t, "secret"),
+		}.httpConfig(),
+	})
+	if err != nil {
+		t.Fatalf("newPushFunc() failed: %v", err)
+	}
+
+	g, err := newCollectTestCollector(t, "group").Gatherer(context.Background())
+	if err != nil {
+		t.Fatalf("Gatherer() failed: %v", err)
+	}
+
+	if err := fn(context.Background(), g); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+
+	r := <-requests
+
+	if r.Method != http.MethodPut || r.URL.Path != "/metrics/job/paperless" {
+		t.Errorf("Got %s %s, want PUT /metrics/job/paperless", r.Method, r.URL.Path)
+	}
+
+	if user, password, ok := r.BasicAuth(); !ok || user != "pusher" || password != "secret" {
+		t.Errorf("Got basic auth %q/%q (%v), want pusher/secret", user, password, ok)
+	}
+}
+
+func TestNewPushFuncInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts pushOptions
+	}{
+		{name: "missing URL", opts: pushOptions{mode: pushModePushgateway}},
+		{name: "unknown mode", opts: pushOptions{url: "http://localhost", mode: "carrier-pigeon"}},
+		{
+			name: "conflicting auth",
+			opts: pushOptions{
+				url:  "http://localhost",
+				mode: pushModePushgateway,
+				httpConfig: pushAuthOptions{
+					username:        "user",
+					bearerTokenFile: "/token",
+				}.httpConfig(),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newPushFunc(tc.opts)
+
+			if diff := cmp.Diff(cmpopts.AnyError, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("newPushFunc() error diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPushLoop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	var calls int
+
+	pushLoop(ctx, slog.New(slog.DiscardHandler), newCollectTestCollector(t, "group"), time.Millisecond,
+		func(context.Context, prometheus.Gatherer) error {
+			calls++
+
+			if calls == 3 {
+				cancel()
+			}
+
+			return nil
+		})
+
+	if calls != 3 {
+		t.Errorf("Push function called %d times, want 3", calls)
+	}
+}
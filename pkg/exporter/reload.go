@@ -0,0 +1,213 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hansmi/paperhooks/pkg/client"
+	"github.com/hansmi/prometheus-paperless-exporter/pkg/collector"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// exporterState contains the collectors built from one configuration.
+type exporterState struct {
+	// Collector for the default instance, if any.
+	collector *collector.Collector
+
+	probe  *probeHandler
+	cancel context.CancelFunc
+
+	// Default instance, if any, followed by all probe targets.
+	instances []instance
+}
+
+// newExporterState builds collectors for the default instance and all probe
+// targets and launches their background loops. The default client flags are
+// used unless the configuration defines the default instance. The wrapper, if
+// non-nil, decorates the HTTP transport of all clients. Warnings and
+// collection status of the previous state, if any, are carried over to the
+// collectors of the same instances.
+func newExporterState(cfg *config, opts collector.Options, wrap transportWrapper, clientFlags client.Flags, requireDefault bool, prev *exporterState) (*exporterState, error) {
+	cfg.apply(&opts)
+
+	s := &exporterState{}
+
+	var prevProbe *probeHandler
+
+	if prev != nil {
+		prevProbe = prev.probe
+	}
+
+	if cfg.Paperless != nil {
+		clientFlags = cfg.Paperless.clientFlags()
+		requireDefault = true
+	}
+
+	if requireDefault || clientFlags.BaseURL != "" {
+		cl, err := buildClient(clientFlags, wrap)
+		if err != nil {
+			return nil, err
+		}
+
+		defaultOpts := opts
+
+		if prev != nil {
+			defaultOpts.Previous = prev.collector
+		}
+
+		c, err := collector.New(cl, defaultOpts)
+		if err != nil {
+			return nil, err
+		}
+
+		s.collector = c
+		s.instances = append(s.instances, instance{
+			name:      defaultInstanceName,
+			baseURL:   clientFlags.BaseURL,
+			version:   newVersionCache(cl),
+			collector: c,
+		})
+	}
+
+	if len(cfg.Targets) > 0 {
+		probe, err := newProbeHandler(cfg.Targets, opts, wrap, prevProbe)
+		if err != nil {
+			return nil, fmt.Errorf("probe: %w", err)
+		}
+
+		s.probe = probe
+		s.instances = append(s.instances, probe.instances...)
+	}
+
+	var ctx context.Context
+
+	ctx, s.cancel = context.WithCancel(context.Background())
+
+	if s.collector != nil {
+		s.collector.Start(ctx)
+	}
+
+	if s.probe != nil {
+		s.probe.start(ctx)
+	}
+
+	return s, nil
+}
+
+// reloader rebuilds the exporter state on request. Failed reloads keep the
+// previous state active.
+type reloader struct {
+	logger *slog.Logger
+	build  func(prev *exporterState) (*exporterState, error)
+
+	mu    sync.Mutex
+	state atomic.Pointer[exporterState]
+
+	// Status of configuration reloads.
+	successGauge     prometheus.Gauge
+	successTimeGauge prometheus.Gauge
+}
+
+func newReloader(logger *slog.Logger, build func(prev *exporterState) (*exporterState, error)) *reloader {
+	return &reloader{
+		logger: logger,
+		build:  build,
+
+		successGauge: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "paperless_exporter_config_last_reload_successful",
+			Help: "Whether the last configuration reload attempt was successful.",
+		}),
+		successTimeGauge: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "paperless_exporter_config_last_reload_success_timestamp_seconds",
+			Help: "Number of seconds since 1970 of the last successful configuration reload.",
+		}),
+	}
+}
+
+// reload builds a new state and replaces the current one on success.
+func (r *reloader) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, err := r.build(r.state.Load())
+	if err != nil {
+		r.successGauge.Set(0)
+		return err
+	}
+
+	if old := r.state.Swap(s); old != nil {
+		old.cancel()
+	}
+
+	r.successGauge.Set(1)
+	r.successTimeGauge.SetToCurrentTime()
+
+	return nil
+}
+
+// metricsHandler serves the metrics of the default instance in the current
+// state along with the metrics from the given gatherer.
+func (r *reloader) metricsHandler(exporter prometheus.Gatherer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var c *collector.Collector
+
+		if s := r.state.Load(); s != nil {
+			c = s.collector
+		}
+
+		serveMetrics(w, req, c, exporter)
+	})
+}
+
+// serveProbe forwards requests to the probe handler of the current state.
+func (r *reloader) serveProbe(w http.ResponseWriter, req *http.Request) {
+	s := r.state.Load()
+
+	if s == nil || s.probe == nil {
+		http.Error(w, "No probe targets configured", http.StatusNotFound)
+		return
+	}
+
+	s.probe.ServeHTTP(w, req)
+}
+
+// serveReload triggers a reload via HTTP POST.
+func (r *reloader) serveReload(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Only POST requests allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.reload(); err != nil {
+		r.logger.Error("Configuration reload failed", "err", err)
+		http.Error(w, fmt.Sprintf("Reload failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	r.logger.Info("Configuration reloaded")
+}
+
+// watchSignals reloads on every signal received from the channel.
+func (r *reloader) watchSignals(ctx context.Context, ch <-chan os.Signal) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ch:
+			start := time.Now()
+
+			if err := r.reload(); err != nil {
+				r.logger.Error("Configuration reload failed", "err", err)
+			} else {
+				r.logger.Info("Configuration reloaded", "duration", time.Since(start))
+			}
+		}
+	}
+}
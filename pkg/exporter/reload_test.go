@@ -0,0 +1,172 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/hansmi/paperhooks/pkg/client"
+	"github.com/hansmi/prometheus-paperless-exporter/pkg/collector"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestReloader(t *testing.T) {
+	errTest := errors.New("test error")
+
+	var buildErr error
+	var generation int64
+
+	r := newReloader(slog.New(slog.NewTextHandler(io.Discard, nil)), func(*exporterState) (*exporterState, error) {
+		if buildErr != nil {
+			return nil, buildErr
+		}
+
+		generation++
+
+		cl := newFakePaperless(t, map[string]string{
+			"/api/groups/": fmt.Sprintf(`{"count": %d, "results": []}`, generation),
+		})
+
+		c, err := collector.New(cl, collector.Options{
+			EnabledIDs: []string{"group"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &exporterState{
+			collector: c,
+			cancel:    func() {},
+		}, nil
+	})
+
+	handler := r.metricsHandler(prometheus.NewPedanticRegistry())
+
+	checkGeneration := func(want int) {
+		t.Helper()
+
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		if wantLine := fmt.Sprintf("paperless_groups %d\n", want); !strings.Contains(rec.Body.String(), wantLine) {
+			t.Errorf("Metrics %q do not contain %q", rec.Body.String(), wantLine)
+		}
+	}
+
+	if err := r.reload(); err != nil {
+		t.Errorf("reload() failed: %v", err)
+	}
+
+	checkGeneration(1)
+
+	buildErr = errTest
+
+	if diff := cmp.Diff(errTest, r.reload(), cmpopts.EquateErrors()); diff != "" {
+		t.Errorf("reload() error diff (-want +got):\n%s", diff)
+	}
+
+	// Previous state remains active.
+	checkGeneration(1)
+
+	if got := testutil.ToFloat64(r.successGauge); got != 0 {
+		t.Errorf("Reload success is %v, want 0", got)
+	}
+
+	buildErr = nil
+
+	for _, tc := range []struct {
+		method     string
+		wantStatus int
+	}{
+		{http.MethodGet, http.StatusMethodNotAllowed},
+		{http.MethodPost, http.StatusOK},
+	} {
+		rec := httptest.NewRecorder()
+
+		r.serveReload(rec, httptest.NewRequest(tc.method, "/-/reload", nil))
+
+		if diff := cmp.Diff(tc.wantStatus, rec.Code); diff != "" {
+			t.Errorf("%s status diff (-want +got):\n%s", tc.method, diff)
+		}
+	}
+
+	checkGeneration(2)
+
+	if got := testutil.ToFloat64(r.successGauge); got != 1 {
+		t.Errorf("Reload success is %v, want 1", got)
+	}
+
+	// No probe targets configured.
+	rec := httptest.NewRecorder()
+
+	r.serveProbe(rec, httptest.NewRequest(http.MethodGet, "/probe?target=x", nil))
+
+	if diff := cmp.Diff(http.StatusNotFound, rec.Code); diff != "" {
+		t.Errorf("Probe status diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestExporterStateCarriesHistory(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(ts.Close)
+
+	cfg := &config{
+		Targets: map[string]targetConfig{
+			"other": {URL: ts.URL},
+		},
+	}
+
+	opts := collector.Options{
+		EnabledIDs: []string{"remote_version"},
+		Logger:     slog.New(slog.DiscardHandler),
+	}
+
+	var prev *exporterState
+
+	for generation := 1; generation <= 3; generation++ {
+		s, err := newExporterState(cfg, opts, nil, client.Flags{BaseURL: ts.URL}, true, prev)
+		if err != nil {
+			t.Fatalf("newExporterState() failed: %v", err)
+		}
+
+		t.Cleanup(s.cancel)
+
+		for _, i := range s.instances {
+			g, err := i.collector.Gatherer(context.Background())
+			if err != nil {
+				t.Fatalf("Gatherer() failed: %v", err)
+			}
+
+			// Each generation adds a warning to those of the previous ones.
+			want := fmt.Sprintf(`
+# HELP paperless_warnings_total Number of warnings generated while scraping metrics.
+# TYPE paperless_warnings_total counter
+paperless_warnings_total{category="collector_refresh"} 0
+paperless_warnings_total{category="get_remote_version"} %d
+paperless_warnings_total{category="invalid_metric"} 0
+paperless_warnings_total{category="timeout"} 0
+paperless_warnings_total{category="unspecified"} 0
+`, generation)
+
+			if err := testutil.GatherAndCompare(g, strings.NewReader(want), "paperless_warnings_total"); err != nil {
+				t.Errorf("Instance %q: %v", i.name, err)
+			}
+
+			if got := len(i.collector.RecentWarnings()); got != generation {
+				t.Errorf("Instance %q has %d recent warnings, want %d", i.name, got, generation)
+			}
+		}
+
+		prev = s
+	}
+}
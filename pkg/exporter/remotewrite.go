@@ -0,0 +1,202 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/snappy"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Maximum number of bytes of an error response included in errors.
+const remoteWriteErrorBodyLimit = 512
+
+type remoteWriteLabel struct {
+	name, value string
+}
+
+type remoteWriteSeries struct {
+	labels    []remoteWriteLabel
+	value     float64
+	timestamp int64
+}
+
+// remoteWriteSeriesFromFamilies flattens metric families into individual
+// series as defined by the Prometheus exposition format, e.g. histograms into
+// their bucket, sum and count series. The extra labels are added to every
+// series unless already present. Labels with an empty value are omitted as
+// Prometheus treats them as absent. Samples without a timestamp use the given
+// default.
+func remoteWriteSeriesFromFamilies(families []*dto.MetricFamily, extra []remoteWriteLabel, now time.Time) []remoteWriteSeries {
+	var result []remoteWriteSeries
+
+	for _, mf := range families {
+		name := mf.GetName()
+
+		for _, m := range mf.GetMetric() {
+			timestamp := now.UnixMilli()
+
+			if m.TimestampMs != nil {
+				timestamp = m.GetTimestampMs()
+			}
+
+			add := func(suffix string, value float64, labels ...remoteWriteLabel) {
+				labels = append(labels, remoteWriteLabel{"__name__", name + suffix})
+
+				for _, lp := range m.GetLabel() {
+					if lp.GetValue() != "" {
+						labels = append(labels, remoteWriteLabel{lp.GetName(), lp.GetValue()})
+					}
+				}
+
+				for _, l := range extra {
+					if l.value != "" && !slices.ContainsFunc(labels, func(existing remoteWriteLabel) bool {
+						return existing.name == l.name
+					}) {
+						labels = append(labels, l)
+					}
+				}
+
+				slices.SortFunc(labels, func(a, b remoteWriteLabel) int {
+					return strings.Compare(a.name, b.name)
+				})
+
+				result = append(result, remoteWriteSeries{
+					labels:    labels,
+					value:     value,
+					timestamp: timestamp,
+				})
+			}
+
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				add("", m.GetCounter().GetValue())
+
+			case dto.MetricType_GAUGE:
+				add("", m.GetGauge().GetValue())
+
+			case dto.MetricType_SUMMARY:
+				s := m.GetSummary()
+
+				for _, q := range s.GetQuantile() {
+					add("", q.GetValue(), remoteWriteLabel{"quantile", formatFloat(q.GetQuantile())})
+				}
+
+				add("_sum", s.GetSampleSum())
+				add("_count", float64(s.GetSampleCount()))
+
+			case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+				h := m.GetHistogram()
+				hasInf := false
+
+				for _, b := range h.GetBucket() {
+					if math.IsInf(b.GetUpperBound(), +1) {
+						hasInf = true
+					}
+
+					add("_bucket", float64(b.GetCumulativeCount()), remoteWriteLabel{"le", formatFloat(b.GetUpperBound())})
+				}
+
+				if !hasInf {
+					add("_bucket", float64(h.GetSampleCount()), remoteWriteLabel{"le", "+Inf"})
+				}
+
+				add("_sum", h.GetSampleSum())
+				add("_count", float64(h.GetSampleCount()))
+
+			default:
+				add("", m.GetUntyped().GetValue())
+			}
+		}
+	}
+
+	return result
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, +1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// encodeWriteRequest serializes the series as a remote-write 1.0
+// WriteRequest protocol buffer message.
+func encodeWriteRequest(series []remoteWriteSeries) []byte {
+	var result []byte
+
+	for _, s := range series {
+		var ts []byte
+
+		for _, l := range s.labels {
+			var label []byte
+
+			label = protowire.AppendTag(label, 1, protowire.BytesType)
+			label = protowire.AppendString(label, l.name)
+			label = protowire.AppendTag(label, 2, protowire.BytesType)
+			label = protowire.AppendString(label, l.value)
+
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, label)
+		}
+
+		var sample []byte
+
+		sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+		sample = protowire.AppendFixed64(sample, math.Float64bits(s.value))
+		sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+		sample = protowire.AppendVarint(sample, uint64(s.timestamp))
+
+		ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+		ts = protowire.AppendBytes(ts, sample)
+
+		result = protowire.AppendTag(result, 1, protowire.BytesType)
+		result = protowire.AppendBytes(result, ts)
+	}
+
+	return result
+}
+
+// sendRemoteWrite sends the series to a remote-write receiver.
+func sendRemoteWrite(ctx context.Context, hc *http.Client, url string, series []remoteWriteSeries) error {
+	body := snappy.Encode(nil, encodeWriteRequest(series))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, remoteWriteErrorBodyLimit))
+
+		return fmt.Errorf("remote write: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	io.Copy(io.Discard, resp.Body)
+
+	return nil
+}
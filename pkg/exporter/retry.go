@@ -0,0 +1,170 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// retryPolicy retries idempotent API requests failing with transient errors.
+type retryPolicy struct {
+	// Maximum number of retries after the initial attempt.
+	maxRetries int
+
+	// Backoff delay before the first retry. Doubled for every further retry
+	// up to maxDelay.
+	baseDelay time.Duration
+	maxDelay  time.Duration
+
+	now   func() time.Time
+	sleep func(context.Context, time.Duration) error
+}
+
+func newRetryPolicy(maxRetries int) *retryPolicy {
+	return &retryPolicy{
+		maxRetries: maxRetries,
+		baseDelay:  250 * time.Millisecond,
+		maxDelay:   5 * time.Second,
+		now:        time.Now,
+		sleep:      sleepContext,
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// wrap returns a transport retrying failed requests.
+func (p *retryPolicy) wrap(base http.RoundTripper) http.RoundTripper {
+	if p.maxRetries < 1 {
+		return base
+	}
+
+	return &retryTransport{policy: p, base: base}
+}
+
+// backoff returns the jittered delay before the given retry (starting at 0).
+func (p *retryPolicy) backoff(retry int) time.Duration {
+	delay := p.maxDelay
+
+	if retry < 30 {
+		delay = min(p.maxDelay, p.baseDelay<<retry)
+	}
+
+	if delay <= 1 {
+		return delay
+	}
+
+	half := delay / 2
+
+	return half + rand.N(delay-half)
+}
+
+func isRetryableError(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// parseRetryAfter returns the delay requested by a Retry-After header, either
+// in seconds or as an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.ParseUint(value, 10, 32); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if ts, err := http.ParseTime(value); err == nil {
+		return max(0, ts.Sub(now)), true
+	}
+
+	return 0, false
+}
+
+type retryTransport struct {
+	policy *retryPolicy
+	base   http.RoundTripper
+}
+
+var _ http.RoundTripper = (*retryTransport)(nil)
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !(req.Method == http.MethodGet || req.Method == http.MethodHead) {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+
+	for retry := 0; ; retry++ {
+		resp, err := t.base.RoundTrip(req)
+
+		if retry >= t.policy.maxRetries || ctx.Err() != nil {
+			return resp, err
+		}
+
+		var delay time.Duration
+
+		if err != nil {
+			if !isRetryableError(err) {
+				return nil, err
+			}
+
+			delay = t.policy.backoff(retry)
+		} else {
+			if !isRetryableStatus(resp.StatusCode) {
+				return resp, nil
+			}
+
+			var ok bool
+
+			if delay, ok = parseRetryAfter(resp.Header.Get("Retry-After"), t.policy.now()); !ok {
+				delay = t.policy.backoff(retry)
+			}
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && t.policy.now().Add(delay).After(deadline) {
+			// Retrying would exceed the deadline.
+			return resp, err
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+			resp.Body.Close()
+		}
+
+		if err := t.policy.sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
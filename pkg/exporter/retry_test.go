@@ -0,0 +1,264 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+type fakeResult struct {
+	code       int
+	retryAfter string
+	err        error
+}
+
+func (r fakeResult) response() (*http.Response, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	resp := &http.Response{
+		StatusCode: r.code,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader("")),
+	}
+
+	if r.retryAfter != "" {
+		resp.Header.Set("Retry-After", r.retryAfter)
+	}
+
+	return resp, nil
+}
+
+func TestRetryTransport(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		method     string
+		deadline   time.Duration
+		results    []fakeResult
+		wantCode   int
+		wantErr    error
+		wantCalls  int
+		wantDelays []time.Duration
+	}{
+		{
+			name:      "success",
+			results:   []fakeResult{{code: http.StatusOK}},
+			wantCode:  http.StatusOK,
+			wantCalls: 1,
+		},
+		{
+			name:      "not retryable",
+			results:   []fakeResult{{code: http.StatusNotFound}},
+			wantCode:  http.StatusNotFound,
+			wantCalls: 1,
+		},
+		{
+			name:      "not idempotent",
+			method:    http.MethodPost,
+			results:   []fakeResult{{code: http.StatusServiceUnavailable}},
+			wantCode:  http.StatusServiceUnavailable,
+			wantCalls: 1,
+		},
+		{
+			name: "server errors",
+			results: []fakeResult{
+				{code: http.StatusBadGateway},
+				{code: http.StatusServiceUnavailable},
+				{code: http.StatusOK},
+			},
+			wantCode:   http.StatusOK,
+			wantCalls:  3,
+			wantDelays: []time.Duration{time.Second, time.Second},
+		},
+		{
+			name: "exhausted",
+			results: []fakeResult{
+				{code: http.StatusInternalServerError},
+				{code: http.StatusInternalServerError},
+				{code: http.StatusInternalServerError},
+				{code: http.StatusOK},
+			},
+			wantCode:   http.StatusInternalServerError,
+			wantCalls:  3,
+			wantDelays: []time.Duration{time.Second, time.Second},
+		},
+		{
+			name: "connection reset",
+			results: []fakeResult{
+				{err: fmt.Errorf("read: %w", syscall.ECONNRESET)},
+				{code: http.StatusOK},
+			},
+			wantCode:   http.StatusOK,
+			wantCalls:  2,
+			wantDelays: []time.Duration{time.Second},
+		},
+		{
+			name: "other error",
+			results: []fakeResult{
+				{err: syscall.EACCES},
+			},
+			wantErr:   syscall.EACCES,
+			wantCalls: 1,
+		},
+		{
+			name: "retry after",
+			results: []fakeResult{
+				{code: http.StatusTooManyRequests, retryAfter: "7"},
+				{code: http.StatusOK},
+			},
+			wantCode:   http.StatusOK,
+			wantCalls:  2,
+			wantDelays: []time.Duration{7 * time.Second},
+		},
+		{
+			name:     "retry after exceeds deadline",
+			deadline: 5 * time.Second,
+			results: []fakeResult{
+				{code: http.StatusTooManyRequests, retryAfter: "7"},
+				{code: http.StatusOK},
+			},
+			wantCode:  http.StatusTooManyRequests,
+			wantCalls: 1,
+		},
+		{
+			name:     "backoff exceeds deadline",
+			deadline: 400 * time.Millisecond,
+			results: []fakeResult{
+				{code: http.StatusBadGateway},
+				{code: http.StatusOK},
+			},
+			wantCode:  http.StatusBadGateway,
+			wantCalls: 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls int
+			var delays []time.Duration
+
+			// Sleeping advances the fake clock only. Deadlines are relative
+			// to the real clock.
+			now := time.Now()
+
+			p := newRetryPolicy(2)
+			p.baseDelay = time.Second
+			p.maxDelay = time.Second
+			p.now = func() time.Time { return now }
+			p.sleep = func(_ context.Context, d time.Duration) error {
+				delays = append(delays, d)
+				now = now.Add(d)
+				return nil
+			}
+
+			rt := p.wrap(roundTripFunc(func(*http.Request) (*http.Response, error) {
+				calls++
+				return tc.results[calls-1].response()
+			}))
+
+			method := tc.method
+
+			if method == "" {
+				method = http.MethodGet
+			}
+
+			ctx := context.Background()
+
+			if tc.deadline > 0 {
+				var cancel context.CancelFunc
+
+				ctx, cancel = context.WithDeadline(ctx, now.Add(tc.deadline))
+				t.Cleanup(cancel)
+			}
+
+			req, err := http.NewRequestWithContext(ctx, method, "http://localhost/api/", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := rt.RoundTrip(req)
+
+			if diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("RoundTrip() error diff (-want +got):\n%s", diff)
+			}
+
+			if err == nil && resp.StatusCode != tc.wantCode {
+				t.Errorf("Got status %d, want %d", resp.StatusCode, tc.wantCode)
+			}
+
+			if calls != tc.wantCalls {
+				t.Errorf("Got %d calls, want %d", calls, tc.wantCalls)
+			}
+
+			// Backoff delays are jittered down to half their nominal value.
+			approx := cmp.Comparer(func(a, b time.Duration) bool {
+				return max(a, b) <= 2*min(a, b)
+			})
+
+			if diff := cmp.Diff(tc.wantDelays, delays, approx); diff != "" {
+				t.Errorf("Delays diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := newRetryPolicy(1)
+
+	for retry, want := range []time.Duration{
+		250 * time.Millisecond,
+		500 * time.Millisecond,
+		time.Second,
+		2 * time.Second,
+		4 * time.Second,
+		5 * time.Second,
+		5 * time.Second,
+	} {
+		if got := p.backoff(retry); got < want/2 || got > want {
+			t.Errorf("backoff(%d) = %v, want between %v and %v", retry, got, want/2, want)
+		}
+	}
+
+	if got := p.backoff(100); got > p.maxDelay {
+		t.Errorf("backoff(100) = %v, want at most %v", got, p.maxDelay)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		value  string
+		want   time.Duration
+		wantOk bool
+	}{
+		{value: ""},
+		{value: "soon"},
+		{value: "-1"},
+		{value: "0", wantOk: true},
+		{value: "120", want: 2 * time.Minute, wantOk: true},
+		{value: "Wed, 01 Jan 2020 00:00:30 GMT", want: 30 * time.Second, wantOk: true},
+		{value: "Tue, 31 Dec 2019 00:00:00 GMT", wantOk: true},
+	} {
+		t.Run(tc.value, func(t *testing.T) {
+			got, ok := parseRetryAfter(tc.value, now)
+
+			if got != tc.want || ok != tc.wantOk {
+				t.Errorf("parseRetryAfter(%q) = (%v, %v), want (%v, %v)", tc.value, got, ok, tc.want, tc.wantOk)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/hansmi/paperhooks/pkg/client"
+	"github.com/hansmi/prometheus-paperless-exporter/pkg/collector"
+)
+
+// Name under which the default instance is listed.
+const defaultInstanceName = "default"
+
+// Timeout for querying the Paperless version of an instance.
+const statusVersionTimeout = 5 * time.Second
+
+// Duration for which the Paperless version of an instance is reused.
+const statusVersionMaxAge = 5 * time.Minute
+
+// instance is a Paperless instance served by the exporter.
+type instance struct {
+	name      string
+	baseURL   string
+	version   *versionCache
+	collector *collector.Collector
+}
+
+// versionCache holds the most recently queried Paperless version of an
+// instance so that loading the status page doesn't send a request every time.
+type versionCache struct {
+	client *client.Client
+	now    func() time.Time
+
+	mu      sync.Mutex
+	fetched time.Time
+	version string
+	err     error
+}
+
+func newVersionCache(cl *client.Client) *versionCache {
+	return &versionCache{
+		client: cl,
+		now:    time.Now,
+	}
+}
+
+// get returns the Paperless version, querying the instance if the cached
+// result is missing or older than statusVersionMaxAge.
+func (c *versionCache) get(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.fetched.IsZero() && c.now().Sub(c.fetched) < statusVersionMaxAge {
+		return c.version, c.err
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, statusVersionTimeout)
+	defer cancel()
+
+	s, _, err := c.client.GetStatus(reqCtx)
+	if err != nil {
+		if ctx.Err() != nil {
+			// Don't keep failures caused by the caller going away.
+			return "", err
+		}
+
+		c.version = ""
+	} else {
+		c.version = s.PNGXVersion
+	}
+
+	c.fetched = c.now()
+	c.err = err
+
+	return c.version, c.err
+}
+
+// redactURL returns the URL without credentials.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+
+	u.User = nil
+
+	return u.String()
+}
+
+type collectorStatus struct {
+	ID                  string     `json:"id"`
+	Enabled             bool       `json:"enabled"`
+	LastRun             *time.Time `json:"last_run,omitempty"`
+	LastDurationSeconds float64    `json:"last_duration_seconds"`
+	LastSuccess         *time.Time `json:"last_success,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastErrorTime       *time.Time `json:"last_error_time,omitempty"`
+}
+
+type instanceStatus struct {
+	Name         string                    `json:"name"`
+	URL          string                    `json:"url"`
+	Version      string                    `json:"version,omitempty"`
+	VersionError string                    `json:"version_error,omitempty"`
+	Collectors   []collectorStatus         `json:"collectors"`
+	Warnings     []collector.WarningRecord `json:"warnings"`
+}
+
+type exporterStatus struct {
+	Instances []instanceStatus `json:"instances"`
+}
+
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
+
+// status returns the status of the instance. The Paperless version is
+// queried from the instance unless a recent result is available.
+func (i *instance) status(ctx context.Context) instanceStatus {
+	result := instanceStatus{
+		Name:     i.name,
+		URL:      redactURL(i.baseURL),
+		Warnings: i.collector.RecentWarnings(),
+	}
+
+	if i.version != nil {
+		if v, err := i.version.get(ctx); err != nil {
+			result.VersionError = err.Error()
+		} else {
+			result.Version = v
+		}
+	}
+
+	enabled := map[string]collector.MemberStatus{}
+
+	for _, s := range i.collector.Status() {
+		enabled[s.ID] = s
+	}
+
+	for _, id := range collector.IDs() {
+		cs := collectorStatus{ID: id}
+
+		if s, ok := enabled[id]; ok {
+			cs.Enabled = true
+			cs.LastRun = timeOrNil(s.LastRun)
+			cs.LastDurationSeconds = s.LastDuration.Seconds()
+			cs.LastSuccess = timeOrNil(s.LastSuccess)
+			cs.LastError = s.LastError
+			cs.LastErrorTime = timeOrNil(s.LastErrorTime)
+		}
+
+		result.Collectors = append(result.Collectors, cs)
+	}
+
+	return result
+}
+
+// status returns the status of all instances in the current state.
+func (r *reloader) status(ctx context.Context) exporterStatus {
+	result := exporterStatus{
+		Instances: []instanceStatus{},
+	}
+
+	s := r.state.Load()
+	if s == nil {
+		return result
+	}
+
+	result.Instances = make([]instanceStatus, len(s.instances))
+
+	var wg sync.WaitGroup
+
+	for idx, i := range s.instances {
+		wg.Go(func() {
+			result.Instances[idx] = i.status(ctx)
+		})
+	}
+
+	wg.Wait()
+
+	return result
+}
+
+var statusTemplate = newPageTemplate("status", `<html>
+<head><title>Paperless Exporter</title></head>
+<body>
+<h1>Paperless Exporter</h1>
+<p><a href="{{.MetricsPath}}">Metrics</a> | <a href="/api/status">Status as JSON</a> | <a href="/debug/warnings">Recent warnings</a></p>
+{{- range .Instances}}
+<h2>{{.Name}}</h2>
+<p>URL: {{.URL}}<br>
+Version: {{if .Version}}{{.Version}}{{else}}unknown{{if .VersionError}} ({{.VersionError}}){{end}}{{end}}</p>
+<table>
+<tr><th>Collector</th><th>Enabled</th><th>Last duration</th><th>Last success</th><th>Last error</th></tr>
+{{- range .Collectors}}
+<tr><td>{{.ID}}</td><td>{{if .Enabled}}yes{{else}}no{{end}}</td><td>{{if .LastRun}}{{printf "%.3fs" .LastDurationSeconds}}{{end}}</td><td>{{with .LastSuccess}}{{.Format "2006-01-02T15:04:05Z07:00"}}{{end}}</td><td>{{with .LastErrorTime}}{{.Format "2006-01-02T15:04:05Z07:00"}}: {{end}}{{.LastError}}</td></tr>
+{{- end}}
+</table>
+<h3>Recent warnings</h3>
+{{- template "warningTable" .Warnings}}
+{{- end}}
+</body>
+</html>
+`)
+
+// statusPageHandler serves a human-readable status page of all instances.
+func (r *reloader) statusPageHandler(metricsPath string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/" {
+			http.NotFound(w, req)
+			return
+		}
+
+		data := struct {
+			MetricsPath string
+			exporterStatus
+		}{metricsPath, r.status(req.Context())}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+		if err := statusTemplate.Execute(w, data); err != nil {
+			r.logger.Error("Rendering status page failed", "err", err)
+		}
+	})
+}
+
+// serveStatusAPI responds with the status of all instances as JSON.
+func (r *reloader) serveStatusAPI(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(r.status(req.Context())); err != nil {
+		r.logger.Error("Encoding status failed", "err", err)
+	}
+}
@@ -0,0 +1,158 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// Instrumentation name of the tracer for API request spans.
+const apiTracerName = "github.com/hansmi/prometheus-paperless-exporter"
+
+// tracingOptions configures the destinations of trace spans.
+type tracingOptions struct {
+	// URL of an OTLP/HTTP endpoint receiving spans, e.g.
+	// "http://localhost:4318/v1/traces".
+	otlpEndpoint string
+
+	// Path to a file to which spans are appended as JSON.
+	file string
+}
+
+// newTracerProvider returns a provider exporting spans to the configured
+// destinations. The returned function flushes pending spans and releases all
+// resources. A no-op provider is returned if no destination is configured.
+func newTracerProvider(ctx context.Context, opts tracingOptions) (trace.TracerProvider, func(context.Context) error, error) {
+	if opts.otlpEndpoint == "" && opts.file == "" {
+		return noop.NewTracerProvider(), func(context.Context) error { return nil }, nil
+	}
+
+	var cleanup []func(context.Context) error
+
+	providerOpts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", "prometheus-paperless-exporter"),
+		)),
+	}
+
+	if opts.otlpEndpoint != "" {
+		exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(opts.otlpEndpoint))
+		if err != nil {
+			return nil, nil, fmt.Errorf("OTLP exporter: %w", err)
+		}
+
+		providerOpts = append(providerOpts, sdktrace.WithBatcher(exporter))
+	}
+
+	if opts.file != "" {
+		f, err := os.OpenFile(opts.file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		cleanup = append(cleanup, func(context.Context) error {
+			return f.Close()
+		})
+
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, nil, fmt.Errorf("file exporter: %w", err)
+		}
+
+		providerOpts = append(providerOpts, sdktrace.WithBatcher(exporter))
+	}
+
+	tp := sdktrace.NewTracerProvider(providerOpts...)
+
+	shutdown := func(ctx context.Context) error {
+		err := tp.Shutdown(ctx)
+
+		for _, fn := range cleanup {
+			err = errors.Join(err, fn(ctx))
+		}
+
+		return err
+	}
+
+	return tp, shutdown, nil
+}
+
+// apiTracer creates a span for each API request.
+type apiTracer struct {
+	tracer trace.Tracer
+}
+
+func newAPITracer(tp trace.TracerProvider) *apiTracer {
+	return &apiTracer{tracer: tp.Tracer(apiTracerName)}
+}
+
+// wrap returns a transport creating spans for requests.
+func (t *apiTracer) wrap(base http.RoundTripper) http.RoundTripper {
+	return &tracingTransport{tracer: t.tracer, base: base}
+}
+
+type tracingTransport struct {
+	tracer trace.Tracer
+	base   http.RoundTripper
+}
+
+var _ http.RoundTripper = (*tracingTransport)(nil)
+
+// RoundTrip sends the request within a span. The span ends when the response
+// body is closed.
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := normalizeEndpoint(req.URL.Path)
+
+	attrs := []attribute.KeyValue{
+		attribute.String("http.request.method", req.Method),
+		attribute.String("url.path", req.URL.Path),
+		attribute.String("paperless.endpoint", endpoint),
+	}
+
+	if page, err := strconv.Atoi(req.URL.Query().Get("page")); err == nil {
+		attrs = append(attrs, attribute.Int("paperless.page", page))
+	}
+
+	ctx, span := t.tracer.Start(req.Context(), req.Method+" "+endpoint,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.End()
+
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+
+	body := &countingBody{ReadCloser: resp.Body}
+	body.done = sync.OnceFunc(func() {
+		span.SetAttributes(attribute.Int64("http.response.body.size", body.count))
+		span.End()
+	})
+
+	resp.Body = body
+
+	return resp, nil
+}
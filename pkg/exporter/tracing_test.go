@@ -0,0 +1,151 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracingTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/broken/" {
+			http.Error(w, "broken", http.StatusInternalServerError)
+			return
+		}
+
+		w.Write(make([]byte, 100))
+	}))
+	t.Cleanup(srv.Close)
+
+	exporter := tracetest.NewInMemoryExporter()
+	rt := newAPITracer(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))).wrap(http.DefaultTransport)
+
+	for _, path := range []string{"/api/documents/12/?page=3", "/api/broken/"} {
+		if err := doRequest(context.Background(), t, rt, srv.URL+path); err != nil {
+			t.Errorf("Request for %q failed: %v", path, err)
+		}
+	}
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	if err := doRequest(context.Background(), t, rt, closed.URL+"/api/tags/"); err == nil {
+		t.Errorf("Request succeeded, want error")
+	}
+
+	type span struct {
+		Name   string
+		Status codes.Code
+		Attrs  map[string]string
+	}
+
+	var got []span
+
+	for _, s := range exporter.GetSpans() {
+		attrs := map[string]string{}
+
+		for _, kv := range s.Attributes {
+			attrs[string(kv.Key)] = kv.Value.Emit()
+		}
+
+		got = append(got, span{s.Name, s.Status.Code, attrs})
+	}
+
+	want := []span{
+		{
+			Name: "GET /api/documents/{id}/",
+			Attrs: map[string]string{
+				"http.request.method":       "GET",
+				"url.path":                  "/api/documents/12/",
+				"paperless.endpoint":        "/api/documents/{id}/",
+				"paperless.page":            "3",
+				"http.response.status_code": "200",
+				"http.response.body.size":   "100",
+			},
+		},
+		{
+			Name:   "GET /api/broken/",
+			Status: codes.Error,
+			Attrs: map[string]string{
+				"http.request.method":       "GET",
+				"url.path":                  "/api/broken/",
+				"paperless.endpoint":        "/api/broken/",
+				"http.response.status_code": "500",
+				"http.response.body.size":   "7",
+			},
+		},
+		{
+			Name:   "GET /api/tags/",
+			Status: codes.Error,
+			Attrs: map[string]string{
+				"http.request.method": "GET",
+				"url.path":            "/api/tags/",
+				"paperless.endpoint":  "/api/tags/",
+			},
+		},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Span diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestTracerProviderFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.json")
+
+	tp, shutdown, err := newTracerProvider(context.Background(), tracingOptions{file: path})
+	if err != nil {
+		t.Fatalf("newTracerProvider() failed: %v", err)
+	}
+
+	_, span := tp.Tracer("test").Start(context.Background(), "scrape")
+	span.End()
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown failed: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() failed: %v", err)
+	}
+
+	var got struct {
+		Name string
+	}
+
+	if err := json.Unmarshal(content, &got); err != nil {
+		t.Fatalf("Decoding spans failed: %v", err)
+	}
+
+	if got.Name != "scrape" {
+		t.Errorf("Span name = %q, want %q", got.Name, "scrape")
+	}
+}
+
+func TestTracerProviderDisabled(t *testing.T) {
+	tp, shutdown, err := newTracerProvider(context.Background(), tracingOptions{})
+	if err != nil {
+		t.Fatalf("newTracerProvider() failed: %v", err)
+	}
+
+	_, span := tp.Tracer("test").Start(context.Background(), "scrape")
+	span.End()
+
+	if span.IsRecording() {
+		t.Errorf("Span is recording")
+	}
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown failed: %v", err)
+	}
+}
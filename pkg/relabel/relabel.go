@@ -0,0 +1,258 @@
+// Package relabel implements a subset of the Prometheus relabeling rules
+// using the same configuration format as metric_relabel_configs.
+package relabel
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"strings"
+)
+
+// Action is the relabeling action to perform.
+type Action string
+
+const (
+	Replace   Action = "replace"
+	Keep      Action = "keep"
+	Drop      Action = "drop"
+	HashMod   Action = "hashmod"
+	LabelMap  Action = "labelmap"
+	LabelDrop Action = "labeldrop"
+	LabelKeep Action = "labelkeep"
+	Lowercase Action = "lowercase"
+	Uppercase Action = "uppercase"
+)
+
+// MetricNameLabel is the pseudo-label containing the metric name.
+const MetricNameLabel = "__name__"
+
+var labelNameRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// IsValidLabelName reports whether the name is a valid label name.
+func IsValidLabelName(name string) bool {
+	return labelNameRe.MatchString(name)
+}
+
+// Regexp is a regular expression anchored at both ends.
+type Regexp struct {
+	*regexp.Regexp
+
+	original string
+}
+
+// NewRegexp compiles an anchored regular expression.
+func NewRegexp(s string) (Regexp, error) {
+	re, err := regexp.Compile("^(?s:" + s + ")$")
+	if err != nil {
+		return Regexp{}, err
+	}
+
+	return Regexp{Regexp: re, original: s}, nil
+}
+
+// MustNewRegexp is like NewRegexp, but panics on errors.
+func MustNewRegexp(s string) Regexp {
+	re, err := NewRegexp(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return re
+}
+
+func (re *Regexp) UnmarshalYAML(unmarshal func(any) error) error {
+	var s string
+
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+
+	r, err := NewRegexp(s)
+	if err != nil {
+		return err
+	}
+
+	*re = r
+
+	return nil
+}
+
+func (re Regexp) MarshalYAML() (any, error) {
+	return re.original, nil
+}
+
+// String returns the original expression.
+func (re Regexp) String() string {
+	return re.original
+}
+
+// Config is a single relabeling rule.
+type Config struct {
+	SourceLabels []string `yaml:"source_labels,flow,omitempty"`
+	Separator    string   `yaml:"separator,omitempty"`
+	Regex        Regexp   `yaml:"regex,omitempty"`
+	Modulus      uint64   `yaml:"modulus,omitempty"`
+	TargetLabel  string   `yaml:"target_label,omitempty"`
+	Replacement  string   `yaml:"replacement,omitempty"`
+	Action       Action   `yaml:"action,omitempty"`
+}
+
+// DefaultConfig contains the default values applied to unset fields.
+var DefaultConfig = Config{
+	Separator:   ";",
+	Regex:       MustNewRegexp("(.*)"),
+	Replacement: "$1",
+	Action:      Replace,
+}
+
+func (c *Config) UnmarshalYAML(unmarshal func(any) error) error {
+	*c = DefaultConfig
+
+	type plain Config
+
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+
+	if c.Regex.Regexp == nil {
+		c.Regex = DefaultConfig.Regex
+	}
+
+	return c.Validate()
+}
+
+// Validate checks the rule for consistency.
+func (c *Config) Validate() error {
+	switch c.Action {
+	case Replace, HashMod, Lowercase, Uppercase:
+		if c.TargetLabel == "" {
+			return fmt.Errorf("relabel action %q requires a target label", c.Action)
+		}
+
+		if c.Action != Replace && !IsValidLabelName(c.TargetLabel) {
+			return fmt.Errorf("invalid target label %q", c.TargetLabel)
+		}
+
+		if c.Action == HashMod && c.Modulus == 0 {
+			return errors.New("relabel action hashmod requires a non-zero modulus")
+		}
+
+	case Keep, Drop, LabelMap:
+
+	case LabelDrop, LabelKeep:
+		if len(c.SourceLabels) > 0 || c.TargetLabel != "" {
+			return fmt.Errorf("relabel action %q only supports a regex", c.Action)
+		}
+
+	default:
+		return fmt.Errorf("unknown relabel action %q", c.Action)
+	}
+
+	if c.Regex.Regexp == nil {
+		return errors.New("missing regex")
+	}
+
+	return nil
+}
+
+// Process applies the rules to a label set. The returned labels are nil if
+// the set is dropped. The input map is not modified.
+func Process(labels map[string]string, cfgs ...*Config) map[string]string {
+	result := maps.Clone(labels)
+
+	if result == nil {
+		result = map[string]string{}
+	}
+
+	for _, cfg := range cfgs {
+		if !apply(result, cfg) {
+			return nil
+		}
+	}
+
+	return result
+}
+
+func apply(labels map[string]string, cfg *Config) bool {
+	values := make([]string, 0, len(cfg.SourceLabels))
+
+	for _, name := range cfg.SourceLabels {
+		values = append(values, labels[name])
+	}
+
+	val := strings.Join(values, cfg.Separator)
+
+	switch cfg.Action {
+	case Keep:
+		return cfg.Regex.MatchString(val)
+
+	case Drop:
+		return !cfg.Regex.MatchString(val)
+
+	case Replace:
+		indexes := cfg.Regex.FindStringSubmatchIndex(val)
+		if indexes == nil {
+			break
+		}
+
+		target := string(cfg.Regex.ExpandString(nil, cfg.TargetLabel, val, indexes))
+		if !IsValidLabelName(target) {
+			break
+		}
+
+		res := string(cfg.Regex.ExpandString(nil, cfg.Replacement, val, indexes))
+		if res == "" {
+			delete(labels, target)
+		} else {
+			labels[target] = res
+		}
+
+	case Lowercase:
+		labels[cfg.TargetLabel] = strings.ToLower(val)
+
+	case Uppercase:
+		labels[cfg.TargetLabel] = strings.ToUpper(val)
+
+	case HashMod:
+		sum := md5.Sum([]byte(val))
+		mod := binary.BigEndian.Uint64(sum[8:]) % cfg.Modulus
+
+		labels[cfg.TargetLabel] = fmt.Sprint(mod)
+
+	case LabelMap:
+		mapped := map[string]string{}
+
+		for name, value := range labels {
+			if cfg.Regex.MatchString(name) {
+				target := cfg.Regex.ReplaceAllString(name, cfg.Replacement)
+
+				if IsValidLabelName(target) {
+					mapped[target] = value
+				}
+			}
+		}
+
+		maps.Copy(labels, mapped)
+
+	case LabelDrop:
+		for name := range labels {
+			if cfg.Regex.MatchString(name) {
+				delete(labels, name)
+			}
+		}
+
+	case LabelKeep:
+		// The metric name is always retained.
+		for name := range labels {
+			if name != MetricNameLabel && !cfg.Regex.MatchString(name) {
+				delete(labels, name)
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,416 @@
+package relabel
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.yaml.in/yaml/v2"
+)
+
+func TestRegexp(t *testing.T) {
+	for _, tc := range []struct {
+		expr  string
+		input string
+		want  bool
+	}{
+		{expr: "tag", input: "tag", want: true},
+		{expr: "tag", input: "tags"},
+		{expr: "tag", input: "paperless_tag"},
+		{expr: "tag|document", input: "document", want: true},
+		{expr: "a.c", input: "a\nc", want: true},
+		{expr: "", input: "", want: true},
+		{expr: "", input: "tag"},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			re := MustNewRegexp(tc.expr)
+
+			if got := re.MatchString(tc.input); got != tc.want {
+				t.Errorf("MatchString(%q) = %v, want %v", tc.input, got, tc.want)
+			}
+
+			if got := re.String(); got != tc.expr {
+				t.Errorf("String() = %q, want %q", got, tc.expr)
+			}
+		})
+	}
+
+	if _, err := NewRegexp("("); err == nil {
+		t.Errorf("NewRegexp() succeeded, want error")
+	}
+}
+
+func TestConfigUnmarshalYAML(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		input   string
+		want    Config
+		wantErr bool
+	}{
+		{
+			name:  "defaults",
+			input: `target_label: tag`,
+			want: Config{
+				Separator:   ";",
+				Regex:       MustNewRegexp("(.*)"),
+				TargetLabel: "tag",
+				Replacement: "$1",
+				Action:      Replace,
+			},
+		},
+		{
+			name: "drop",
+			input: `
+source_labels: [__name__, id]
+separator: ","
+regex: paperless_tag_.*
+action: drop
+`,
+			want: Config{
+				SourceLabels: []string{"__name__", "id"},
+				Separator:    ",",
+				Regex:        MustNewRegexp("paperless_tag_.*"),
+				Replacement:  "$1",
+				Action:       Drop,
+			},
+		},
+		{
+			name:    "replace without target label",
+			input:   `action: replace`,
+			wantErr: true,
+		},
+		{
+			name:    "hashmod without modulus",
+			input:   `{action: hashmod, target_label: shard}`,
+			wantErr: true,
+		},
+		{
+			name:    "lowercase with invalid target label",
+			input:   `{action: lowercase, target_label: "a-b"}`,
+			wantErr: true,
+		},
+		{
+			name:    "labeldrop with source labels",
+			input:   `{action: labeldrop, source_labels: [id]}`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown action",
+			input:   `{action: rename, target_label: tag}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid regex",
+			input:   `{regex: "(", target_label: tag}`,
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got Config
+
+			err := yaml.UnmarshalStrict([]byte(tc.input), &got)
+
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("Unmarshalling succeeded, want error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unmarshalling failed: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.want, got, cmp.Comparer(func(a, b Regexp) bool {
+				return a.String() == b.String()
+			})); diff != "" {
+				t.Errorf("Config diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestProcess(t *testing.T) {
+	input := map[string]string{
+		"__name__": "paperless_tag_info",
+		"id":       "12",
+		"name":     "Invoices",
+		"slug":     "invoices",
+	}
+
+	for _, tc := range []struct {
+		name string
+		cfg  Config
+		want map[string]string
+	}{
+		{
+			name: "keep",
+			cfg: Config{
+				SourceLabels: []string{"__name__"},
+				Regex:        MustNewRegexp("paperless_tag_.*"),
+				Action:       Keep,
+			},
+			want: input,
+		},
+		{
+			name: "keep mismatch",
+			cfg: Config{
+				SourceLabels: []string{"__name__"},
+				Regex:        MustNewRegexp("paperless_tag"),
+				Action:       Keep,
+			},
+		},
+		{
+			name: "drop",
+			cfg: Config{
+				SourceLabels: []string{"id", "slug"},
+				Separator:    ";",
+				Regex:        MustNewRegexp("12;inv.*"),
+				Action:       Drop,
+			},
+		},
+		{
+			name: "drop mismatch",
+			cfg: Config{
+				SourceLabels: []string{"slug"},
+				Regex:        MustNewRegexp("inv"),
+				Action:       Drop,
+			},
+			want: input,
+		},
+		{
+			name: "replace",
+			cfg: Config{
+				SourceLabels: []string{"slug"},
+				Regex:        MustNewRegexp("inv(.*)"),
+				TargetLabel:  "short",
+				Replacement:  "${1}",
+				Action:       Replace,
+			},
+			want: map[string]string{
+				"__name__": "paperless_tag_info",
+				"id":       "12",
+				"name":     "Invoices",
+				"slug":     "invoices",
+				"short":    "oices",
+			},
+		},
+		{
+			name: "replace mismatch",
+			cfg: Config{
+				SourceLabels: []string{"slug"},
+				Regex:        MustNewRegexp("voices"),
+				TargetLabel:  "short",
+				Replacement:  "x",
+				Action:       Replace,
+			},
+			want: input,
+		},
+		{
+			name: "replace with expanded target label",
+			cfg: Config{
+				SourceLabels: []string{"id"},
+				Regex:        MustNewRegexp("(.*)"),
+				TargetLabel:  "tag_$1",
+				Replacement:  "yes",
+				Action:       Replace,
+			},
+			want: map[string]string{
+				"__name__": "paperless_tag_info",
+				"id":       "12",
+				"name":     "Invoices",
+				"slug":     "invoices",
+				"tag_12":   "yes",
+			},
+		},
+		{
+			name: "replace with invalid target label",
+			cfg: Config{
+				SourceLabels: []string{"id"},
+				Regex:        MustNewRegexp("(.*)"),
+				TargetLabel:  "$1",
+				Replacement:  "yes",
+				Action:       Replace,
+			},
+			want: input,
+		},
+		{
+			name: "replace with empty value",
+			cfg: Config{
+				SourceLabels: []string{"other"},
+				Regex:        MustNewRegexp("(.*)"),
+				TargetLabel:  "name",
+				Replacement:  "$1",
+				Action:       Replace,
+			},
+			want: map[string]string{
+				"__name__": "paperless_tag_info",
+				"id":       "12",
+				"slug":     "invoices",
+			},
+		},
+		{
+			name: "lowercase",
+			cfg: Config{
+				SourceLabels: []string{"name"},
+				TargetLabel:  "name",
+				Action:       Lowercase,
+			},
+			want: map[string]string{
+				"__name__": "paperless_tag_info",
+				"id":       "12",
+				"name":     "invoices",
+				"slug":     "invoices",
+			},
+		},
+		{
+			name: "uppercase",
+			cfg: Config{
+				SourceLabels: []string{"slug"},
+				TargetLabel:  "upper",
+				Action:       Uppercase,
+			},
+			want: map[string]string{
+				"__name__": "paperless_tag_info",
+				"id":       "12",
+				"name":     "Invoices",
+				"slug":     "invoices",
+				"upper":    "INVOICES",
+			},
+		},
+		{
+			name: "hashmod",
+			cfg: Config{
+				SourceLabels: []string{"id"},
+				Modulus:      1,
+				TargetLabel:  "shard",
+				Action:       HashMod,
+			},
+			want: map[string]string{
+				"__name__": "paperless_tag_info",
+				"id":       "12",
+				"name":     "Invoices",
+				"slug":     "invoices",
+				"shard":    "0",
+			},
+		},
+		{
+			name: "labelmap",
+			cfg: Config{
+				Regex:       MustNewRegexp("(name|slug)"),
+				Replacement: "tag_$1",
+				Action:      LabelMap,
+			},
+			want: map[string]string{
+				"__name__": "paperless_tag_info",
+				"id":       "12",
+				"name":     "Invoices",
+				"slug":     "invoices",
+				"tag_name": "Invoices",
+				"tag_slug": "invoices",
+			},
+		},
+		{
+			name: "labeldrop",
+			cfg: Config{
+				Regex:  MustNewRegexp("name|slug"),
+				Action: LabelDrop,
+			},
+			want: map[string]string{
+				"__name__": "paperless_tag_info",
+				"id":       "12",
+			},
+		},
+		{
+			name: "labeldrop is anchored",
+			cfg: Config{
+				Regex:  MustNewRegexp("nam"),
+				Action: LabelDrop,
+			},
+			want: input,
+		},
+		{
+			name: "labelkeep",
+			cfg: Config{
+				Regex:  MustNewRegexp("id"),
+				Action: LabelKeep,
+			},
+			want: map[string]string{
+				"__name__": "paperless_tag_info",
+				"id":       "12",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Process(input, &tc.cfg)
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Process() diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestProcessHashMod(t *testing.T) {
+	cfg := &Config{
+		SourceLabels: []string{"id"},
+		Modulus:      4,
+		TargetLabel:  "shard",
+		Action:       HashMod,
+	}
+
+	for _, id := range []string{"1", "2", "3", "4", "5", "6", "7", "8"} {
+		first := Process(map[string]string{"id": id}, cfg)
+		second := Process(map[string]string{"id": id}, cfg)
+
+		// Shards are stable.
+		if diff := cmp.Diff(first, second); diff != "" {
+			t.Errorf("Process() diff (-first +second):\n%s", diff)
+		}
+
+		if shard := first["shard"]; !slices.Contains([]string{"0", "1", "2", "3"}, shard) {
+			t.Errorf("Shard %q of ID %q is out of range", shard, id)
+		}
+	}
+}
+
+func TestProcessMultiple(t *testing.T) {
+	input := map[string]string{
+		"__name__": "paperless_tag_info",
+		"id":       "12",
+	}
+
+	got := Process(input,
+		&Config{
+			SourceLabels: []string{"id"},
+			Regex:        MustNewRegexp("(.*)"),
+			TargetLabel:  "tag",
+			Replacement:  "tag-$1",
+			Action:       Replace,
+		},
+		&Config{
+			Regex:  MustNewRegexp("id"),
+			Action: LabelDrop,
+		},
+	)
+
+	if diff := cmp.Diff(map[string]string{
+		"__name__": "paperless_tag_info",
+		"tag":      "tag-12",
+	}, got); diff != "" {
+		t.Errorf("Process() diff (-want +got):\n%s", diff)
+	}
+
+	// The input isn't modified.
+	if diff := cmp.Diff(map[string]string{
+		"__name__": "paperless_tag_info",
+		"id":       "12",
+	}, input); diff != "" {
+		t.Errorf("Input diff (-want +got):\n%s", diff)
+	}
+
+	if got := Process(nil); got == nil || len(got) != 0 {
+		t.Errorf("Process(nil) = %v, want empty labels", got)
+	}
+}